@@ -64,6 +64,9 @@ const (
 	FieldSHA1Hash
 	FieldSHA256Hash
 	FieldTraceID
+	FieldQueryParam
+	FieldEmail
+	FieldURL
 )
 
 // ScanValues implements ValueScanner interface
@@ -103,6 +106,8 @@ var (
 	}
 	// registeredFieldNamesJSON stores the JSON field names of registered field ids.
 	registeredFieldNamesJSON = map[FieldID]string{}
+	// registeredFieldMeta stores the metadata of registered indicator fields
+	registeredFieldMeta = map[FieldID]FieldMeta{}
 	// fieldsByName maps field names to ids to ensure field names are distinct in both Go structs and JSON objects.
 	fieldsByName = map[string]FieldID{
 		// Reserve field name for embedded event
@@ -135,6 +140,26 @@ func RegisteredFieldNamesJSON() (names []string) {
 	return
 }
 
+// FieldMetaByID returns the metadata of all registered indicator fields by field id.
+// It creates a new copy so that outside packages cannot affect the registered fields.
+func FieldMetaByID() map[FieldID]FieldMeta {
+	meta := make(map[FieldID]FieldMeta, len(registeredFieldMeta))
+	for id, m := range registeredFieldMeta {
+		meta[id] = m
+	}
+	return meta
+}
+
+// FieldMetaByJSONName returns the metadata of all registered indicator fields by JSON field name.
+// It creates a new copy so that outside packages cannot affect the registered fields.
+func FieldMetaByJSONName() map[string]FieldMeta {
+	meta := make(map[string]FieldMeta, len(registeredFieldMeta))
+	for _, m := range registeredFieldMeta {
+		meta[m.NameJSON] = m
+	}
+	return meta
+}
+
 func init() {
 	MustRegisterIndicator(FieldIPAddress, FieldMeta{
 		Name:        "PantherAnyIPAddresses",
@@ -166,15 +191,33 @@ func init() {
 		NameJSON:    "p_any_trace_ids",
 		Description: "Panther added field with collection of context trace identifiers",
 	})
+	MustRegisterIndicator(FieldQueryParam, FieldMeta{
+		Name:        "PantherAnyQueryParams",
+		NameJSON:    "p_any_query_params",
+		Description: "Panther added field with collection of URL query parameters (as key:value) associated with the row",
+	})
+	MustRegisterIndicator(FieldEmail, FieldMeta{
+		Name:        "PantherAnyEmails",
+		NameJSON:    "p_any_emails",
+		Description: "Panther added field with collection of email addresses associated with the row",
+	})
+	MustRegisterIndicator(FieldURL, FieldMeta{
+		Name:        "PantherAnyURLs",
+		NameJSON:    "p_any_urls",
+		Description: "Panther added field with collection of URLs associated with the row",
+	})
 	MustRegisterScanner("ip", ValueScannerFunc(ScanIPAddress), FieldIPAddress)
 	MustRegisterScanner("domain", FieldDomainName, FieldDomainName)
 	MustRegisterScanner("md5", FieldMD5Hash, FieldMD5Hash)
 	MustRegisterScanner("sha1", FieldSHA1Hash, FieldSHA1Hash)
-	MustRegisterScanner("sha256", FieldSHA256Hash, FieldSHA256Hash)
+	MustRegisterScanner("sha256", ValueScannerFunc(ScanSHA256), FieldSHA256Hash)
 	MustRegisterScanner("hostname", ValueScannerFunc(ScanHostname), FieldDomainName, FieldIPAddress)
-	MustRegisterScanner("url", ValueScannerFunc(ScanURL), FieldDomainName, FieldIPAddress)
+	MustRegisterScanner("url", ValueScannerFunc(ScanURL), FieldURL, FieldDomainName, FieldIPAddress)
 	MustRegisterScanner("trace_id", FieldTraceID, FieldTraceID)
 	MustRegisterScanner("net_addr", ValueScannerFunc(ScanNetworkAddress), FieldIPAddress, FieldDomainName)
+	MustRegisterScanner("ip_list", ValueScannerFunc(ScanIPList), FieldIPAddress)
+	MustRegisterScanner("query_params", ValueScannerFunc(ScanQueryParams), FieldQueryParam)
+	MustRegisterScanner("email", ValueScannerFunc(ScanEmail), FieldEmail)
 }
 
 // MustRegisterIndicator allows modules to define their own indicator fields.
@@ -210,6 +253,7 @@ func RegisterIndicator(id FieldID, field FieldMeta) error {
 	}
 	registeredFields[id] = field.StructField()
 	registeredFieldNamesJSON[id] = field.NameJSON
+	registeredFieldMeta[id] = field
 	// Store both the JSON name and the go field name
 	fieldsByName[field.Name] = id
 	fieldsByName[field.NameJSON] = id
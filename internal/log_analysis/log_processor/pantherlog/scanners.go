@@ -21,6 +21,7 @@ package pantherlog
 import (
 	"net"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -108,16 +109,60 @@ func LookupScanner(name string) (scanner ValueScanner, fields []FieldID) {
 	return
 }
 
-// ScanURL scans a URL string for domain or ip address
+// ScanURL scans a URL string for domain or ip address.
+// URLs without a scheme (ie `example.com/path`) are parsed as if they were `http://` URLs,
+// if their host is an ip address or a domain name with a dot (so that `-` or `index.html` are not URLs).
+// The full URL is also scanned as is, if it has a host.
 func ScanURL(dest ValueWriter, input string) {
+	input = strings.TrimSpace(input)
 	if input == "" {
 		return
 	}
-	u, err := url.Parse(input)
+	rawURL := input
+	hasScheme := strings.Contains(rawURL, "://")
+	if !hasScheme {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
 	if err != nil {
 		return
 	}
-	ScanHostname(dest, u.Hostname())
+	host := u.Hostname()
+	if host == "" {
+		return
+	}
+	if !hasScheme && !checkIPAddress(host) && !isDottedHostname(host) {
+		return
+	}
+	dest.WriteValues(FieldURL, input)
+	ScanHostname(dest, host)
+}
+
+// File extensions which are not top level domains, so that relative paths (ie `index.html`) are not taken for hosts.
+var fileExtensions = map[string]bool{
+	"html": true,
+	"htm":  true,
+	"php":  true,
+	"asp":  true,
+	"aspx": true,
+	"jsp":  true,
+	"js":   true,
+	"css":  true,
+	"json": true,
+	"xml":  true,
+	"txt":  true,
+	"png":  true,
+	"jpg":  true,
+	"gif":  true,
+}
+
+// isDottedHostname checks that a host without a scheme looks like a domain name (ie `example.com`)
+func isDottedHostname(host string) bool {
+	pos := strings.LastIndexByte(host, '.')
+	if pos <= 0 || pos == len(host)-1 {
+		return false
+	}
+	return !fileExtensions[strings.ToLower(host[pos+1:])]
 }
 
 // ScanHostname scans `input` for either an ip address or a domain name value.
@@ -140,6 +185,111 @@ func ScanIPAddress(w ValueWriter, input string) {
 	}
 }
 
+// ScanIPList scans a comma separated list of ip addresses (ie `X-Forwarded-For` header values).
+// Tokens with a port suffix or IPv6 brackets are accepted and tokens that are not valid ip addresses are ignored.
+func ScanIPList(w ValueWriter, input string) {
+	for _, token := range strings.Split(input, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(token); err == nil {
+			token = host
+		}
+		token = strings.TrimSuffix(strings.TrimPrefix(token, "["), "]")
+		ScanIPAddress(w, token)
+	}
+}
+
+// MaxQueryParams is the maximum number of parameters ScanQueryParams will write for a single query string.
+const MaxQueryParams = 32
+
+// ScanQueryParams scans a URL query string (ie `a=b&c=d`) and writes each parameter as a `key:value` value.
+// Keys and values are URL-decoded and repeated keys produce one value per occurrence.
+// Parameters beyond `MaxQueryParams` are ignored.
+func ScanQueryParams(w ValueWriter, input string) {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "?")
+	if input == "" {
+		return
+	}
+	var params []string
+	for _, param := range strings.Split(input, "&") {
+		if len(params) == MaxQueryParams {
+			break
+		}
+		if param == "" {
+			continue
+		}
+		key, value := param, ""
+		if pos := strings.IndexByte(param, '='); pos != -1 {
+			key, value = param[:pos], param[pos+1:]
+		}
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return
+		}
+		if key == "" {
+			continue
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return
+		}
+		params = append(params, key+":"+value)
+	}
+	w.WriteValues(FieldQueryParam, params...)
+}
+
+// Lowercase email addresses with the common subset of RFC5322 (no quoted local parts or IP literal domains)
+var emailRegex = regexp.MustCompile(
+	"^[a-z0-9!#$%&'*+/=?^_`{|}~-]+(?:\\.[a-z0-9!#$%&'*+/=?^_`{|}~-]+)*" +
+		`@[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?)+$`)
+
+// maxEmailLength is the maximum length of an email address (RFC5321)
+const maxEmailLength = 254
+
+// ScanEmail scans `input` for an email address value.
+// Email addresses are normalized to lowercase.
+func ScanEmail(w ValueWriter, input string) {
+	if email, ok := NormalizeEmail(input); ok {
+		w.WriteValues(FieldEmail, email)
+	}
+}
+
+// NormalizeEmail validates an email address and converts it to lowercase.
+func NormalizeEmail(input string) (string, bool) {
+	email := strings.ToLower(strings.TrimSpace(input))
+	if len(email) > maxEmailLength {
+		return "", false
+	}
+	if pos := strings.IndexByte(email, '@'); pos > 64 {
+		// Local part is too long
+		return "", false
+	}
+	if !emailRegex.MatchString(email) {
+		return "", false
+	}
+	return email, true
+}
+
+// ScanSHA256 scans `input` for a SHA256 hash (64 hex digits).
+// Hashes are normalized to lowercase.
+func ScanSHA256(w ValueWriter, input string) {
+	const sha256HexLen = 64
+	input = strings.TrimSpace(input)
+	if len(input) != sha256HexLen {
+		return
+	}
+	for i := 0; i < len(input); i++ {
+		switch c := input[i]; {
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return
+		}
+	}
+	w.WriteValues(FieldSHA256Hash, strings.ToLower(input))
+}
+
 // checkIPAddress checks if an IP address is valid
 // TODO: [performance] Use a simpler method to check ip addresses than net.ParseIP to avoid allocations.
 func checkIPAddress(addr string) bool {
@@ -17,3 +17,153 @@ package pantherlog
  * You should have received a copy of the GNU Affero General Public License
  * along with this program.  If not, see <https://www.gnu.org/licenses/>.
  */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanIPList(t *testing.T) {
+	values := ValueBuffer{}
+	ScanIPList(&values, "1.2.3.4, 5.6.7.8,unknown, ")
+	require.Equal(t, []string{"1.2.3.4", "5.6.7.8"}, values.Get(FieldIPAddress))
+
+	values.Reset()
+	ScanIPList(&values, "[2001:db8::1]:8080, 2001:db8::2,[2001:db8::3]")
+	require.Equal(t, []string{"2001:db8::1", "2001:db8::2", "2001:db8::3"}, values.Get(FieldIPAddress))
+
+	values.Reset()
+	ScanIPList(&values, "10.0.0.1:443,example.com:80")
+	require.Equal(t, []string{"10.0.0.1"}, values.Get(FieldIPAddress))
+	require.Nil(t, values.Get(FieldDomainName))
+
+	scanner, fields := LookupScanner("ip_list")
+	require.NotNil(t, scanner)
+	require.Equal(t, []FieldID{FieldIPAddress}, fields)
+}
+
+func TestScanQueryParams(t *testing.T) {
+	values := ValueBuffer{}
+	ScanQueryParams(&values, "?q=hello%20world&redirect=https%3A%2F%2Fexample.com%2Fa%3Fb%3Dc&empty=&flag")
+	require.Equal(t, []string{
+		"empty:",
+		"flag:",
+		"q:hello world",
+		"redirect:https://example.com/a?b=c",
+	}, values.Get(FieldQueryParam))
+
+	values.Reset()
+	ScanQueryParams(&values, "id=1&id=2&id=1&tag+name=a+b")
+	require.Equal(t, []string{"id:1", "id:2", "tag name:a b"}, values.Get(FieldQueryParam))
+
+	values.Reset()
+	ScanQueryParams(&values, "a=1&b=%zz")
+	require.True(t, values.IsEmpty())
+
+	values.Reset()
+	input := strings.Repeat("a=1&", MaxQueryParams) + "b=2"
+	ScanQueryParams(&values, input)
+	require.Equal(t, []string{"a:1"}, values.Get(FieldQueryParam))
+}
+
+func TestScanEmail(t *testing.T) {
+	for input, expect := range map[string]string{
+		"jane.doe@example.com":        "jane.doe@example.com",
+		" Jane.Doe@Example.COM ":      "jane.doe@example.com",
+		"ops+alerts@sub.example.org":  "ops+alerts@sub.example.org",
+		"o'brien@example.co.uk":       "o'brien@example.co.uk",
+		"plainaddress":                "",
+		"@example.com":                "",
+		"jane@":                       "",
+		"jane@example":                "",
+		"jane..doe@example.com":       "",
+		".jane@example.com":           "",
+		"jane@-example.com":           "",
+		"jane doe@example.com":        "",
+		"jane@example.com, bob@a.com": "",
+	} {
+		values := ValueBuffer{}
+		ScanEmail(&values, input)
+		if expect == "" {
+			require.True(t, values.IsEmpty(), input)
+			continue
+		}
+		require.Equal(t, []string{expect}, values.Get(FieldEmail), input)
+	}
+	values := ValueBuffer{}
+	ScanEmail(&values, strings.Repeat("a", 65)+"@example.com")
+	require.True(t, values.IsEmpty())
+
+	scanner, fields := LookupScanner("email")
+	require.NotNil(t, scanner)
+	require.Equal(t, []FieldID{FieldEmail}, fields)
+}
+
+func TestScanSHA256(t *testing.T) {
+	const hash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	values := ValueBuffer{}
+	ScanSHA256(&values, hash)
+	require.Equal(t, []string{hash}, values.Get(FieldSHA256Hash))
+
+	values = ValueBuffer{}
+	ScanSHA256(&values, strings.ToUpper(hash))
+	require.Equal(t, []string{hash}, values.Get(FieldSHA256Hash))
+
+	values = ValueBuffer{}
+	ScanSHA256(&values, hash[:63])
+	ScanSHA256(&values, hash+"0")
+	ScanSHA256(&values, "z"+hash[1:])
+	require.True(t, values.IsEmpty())
+
+	scanner, fields := LookupScanner("sha256")
+	require.NotNil(t, scanner)
+	require.Equal(t, []FieldID{FieldSHA256Hash}, fields)
+}
+
+func TestScanURL(t *testing.T) {
+	values := ValueBuffer{}
+	ScanURL(&values, "https://www.example.com:8443/path?q=1")
+	require.Equal(t, []string{"https://www.example.com:8443/path?q=1"}, values.Get(FieldURL))
+	require.Equal(t, []string{"www.example.com"}, values.Get(FieldDomainName))
+	require.Nil(t, values.Get(FieldIPAddress))
+
+	values.Reset()
+	ScanURL(&values, "www.example.com/path")
+	require.Equal(t, []string{"www.example.com/path"}, values.Get(FieldURL))
+	require.Equal(t, []string{"www.example.com"}, values.Get(FieldDomainName))
+
+	values.Reset()
+	ScanURL(&values, "http://[2001:db8::1]:8080/index.html")
+	require.Equal(t, []string{"http://[2001:db8::1]:8080/index.html"}, values.Get(FieldURL))
+	require.Equal(t, []string{"2001:db8::1"}, values.Get(FieldIPAddress))
+	require.Nil(t, values.Get(FieldDomainName))
+
+	values.Reset()
+	ScanURL(&values, "10.0.0.1/login")
+	require.Equal(t, []string{"10.0.0.1"}, values.Get(FieldIPAddress))
+
+	values.Reset()
+	ScanURL(&values, "localhost:8080/health")
+	require.True(t, values.IsEmpty())
+	ScanURL(&values, "http://localhost:8080/health")
+	require.Equal(t, []string{"localhost"}, values.Get(FieldDomainName))
+
+	values.Reset()
+	ScanURL(&values, "/relative/path")
+	ScanURL(&values, "http://%zz")
+	ScanURL(&values, "")
+	// Tokens without a scheme are only URLs if their host is an ip address or has a dot
+	ScanURL(&values, "-")
+	ScanURL(&values, "n/a")
+	ScanURL(&values, "none")
+	ScanURL(&values, "index.html")
+	ScanURL(&values, "static/app.js")
+	ScanURL(&values, "example.")
+	require.True(t, values.IsEmpty())
+
+	scanner, fields := LookupScanner("url")
+	require.NotNil(t, scanner)
+	require.Equal(t, []FieldID{FieldURL, FieldDomainName, FieldIPAddress}, fields)
+}
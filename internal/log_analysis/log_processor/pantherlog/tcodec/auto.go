@@ -0,0 +1,152 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Default magnitude thresholds of AutoCodec.
+// Each one is 1e11 seconds (around the year 5138) in the respective unit, so that any timestamp in the
+// foreseeable future in a finer unit is above the threshold of the coarser unit.
+// Timestamps before 1973 in a finer unit (ie `1e11` milliseconds) are mistaken for the coarser unit.
+const (
+	DefaultAutoMaxSeconds      = 1e11
+	DefaultAutoMaxMilliseconds = 1e14
+	DefaultAutoMaxMicroseconds = 1e17
+)
+
+// DefaultAutoLayouts are the layouts AutoCodec tries, in order, to decode string values.
+var DefaultAutoLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"02/Jan/2006:15:04:05 -0700",
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.ANSIC,
+	"2006-01-02",
+}
+
+// AutoTimeCodec decodes timestamps in any of the common formats.
+// It is meant for exploring new log sources whose timestamp format is unknown, prefer an exact codec otherwise.
+//
+// Numbers (and numeric strings) are epoch timestamps, whose unit is chosen by their absolute value:
+//   - below MaxSeconds they are seconds
+//   - below MaxMilliseconds they are milliseconds
+//   - below MaxMicroseconds they are microseconds
+//   - otherwise they are nanoseconds
+//
+// Other strings are decoded with the first of the Layouts that matches.
+// It encodes timestamps to RFC3339Nano strings and zero time to `null`.
+type AutoTimeCodec struct {
+	MaxSeconds      float64
+	MaxMilliseconds float64
+	MaxMicroseconds float64
+	Layouts         []string
+}
+
+// AutoCodec returns an AutoTimeCodec using the default thresholds and layouts.
+func AutoCodec() *AutoTimeCodec {
+	return &AutoTimeCodec{
+		MaxSeconds:      DefaultAutoMaxSeconds,
+		MaxMilliseconds: DefaultAutoMaxMilliseconds,
+		MaxMicroseconds: DefaultAutoMaxMicroseconds,
+		Layouts:         DefaultAutoLayouts,
+	}
+}
+
+var _ TimeCodec = (*AutoTimeCodec)(nil)
+
+func (c *AutoTimeCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteString(tm.Format(time.RFC3339Nano))
+}
+
+func (c *AutoTimeCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		tm, err := c.parseEpoch(string(iter.ReadNumber()))
+		if err != nil {
+			iter.ReportError("ReadAutoTime", err.Error())
+		}
+		return tm
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		for _, layout := range c.Layouts {
+			if tm, err := time.Parse(layout, s); err == nil {
+				return tm
+			}
+		}
+		tm, err := c.parseEpoch(s)
+		if err != nil {
+			iter.ReportError("ReadAutoTime", "unknown time format "+strconv.Quote(s))
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadAutoTime", `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// parseEpoch parses a numeric timestamp choosing the unit by its magnitude.
+func (c *AutoTimeCodec) parseEpoch(s string) (time.Time, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	unit := c.unit(math.Abs(f))
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return unixUnitTime(n, unit).UTC(), nil
+	}
+	return UnixSeconds(f * unit.Seconds()).UTC(), nil
+}
+
+func (c *AutoTimeCodec) unit(abs float64) time.Duration {
+	switch {
+	case abs < c.MaxSeconds:
+		return time.Second
+	case abs < c.MaxMilliseconds:
+		return time.Millisecond
+	case abs < c.MaxMicroseconds:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
@@ -0,0 +1,96 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoCodec(t *testing.T) {
+	codec := AutoCodec()
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, input := range []string{
+		`1577977445 `,
+		`1577977445000 `,
+		`1577977445000000 `,
+		`1577977445000000000 `,
+		`"1577977445000"`,
+		`1577977445.0 `,
+		`"2020-01-02T15:04:05Z"`,
+		`"2020-01-02T17:04:05.000+02:00"`,
+		`"Thu, 02 Jan 2020 15:04:05 GMT"`,
+		`"Thu, 02 Jan 2020 10:04:05 -0500"`,
+		`"2020-01-02 15:04:05"`,
+		`"2020-01-02T15:04:05"`,
+		`"02/Jan/2020:15:04:05 +0000"`,
+		`"Thu Jan  2 15:04:05 2020"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, expect.Equal(actual), "%s %s", input, actual)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577977445123 `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect.Add(123*time.Millisecond), actual)
+	}
+	for _, input := range []string{`"yesterday"`, `{}`, `true`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+	for _, input := range []string{`null`, `""`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, actual.IsZero(), input)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(expect.Add(time.Millisecond), stream)
+		require.Equal(t, `"2020-01-02T15:04:05.001Z"`, string(stream.Buffer()))
+		stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
+
+func TestAutoCodecThresholds(t *testing.T) {
+	codec := AutoCodec()
+	// Only seconds and milliseconds
+	codec.MaxMilliseconds = math.Inf(1)
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577977445000000 `)
+	actual := codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Unix(1577977445000, 0).UTC(), actual)
+
+	// Small values are seconds by default
+	codec = AutoCodec()
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `-86400 `)
+	actual = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), actual)
+}
@@ -0,0 +1,603 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// SplunkTimeCodec decodes/encodes Splunk `_time` values which are seconds since UNIX epoch with a 3-digit fraction.
+// Values are decoded as integer seconds and milliseconds to avoid float rounding (ie `"1577923200.001"`).
+// It decodes both string and number JSON values and encodes always to a string with exactly 3 decimals.
+func SplunkTimeCodec() TimeCodec {
+	return &splunkTimeCodec{}
+}
+
+type splunkTimeCodec struct{}
+
+func (*splunkTimeCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	msec := tm.UnixNano() / int64(time.Millisecond)
+	buf := stream.Buffer()
+	buf = append(buf, '"')
+	if msec < 0 {
+		buf = append(buf, '-')
+		msec = -msec
+	}
+	buf = strconv.AppendInt(buf, msec/1000, 10)
+	buf = append(buf, '.')
+	frac := msec % 1000
+	switch {
+	case frac < 10:
+		buf = append(buf, '0', '0')
+	case frac < 100:
+		buf = append(buf, '0')
+	}
+	buf = strconv.AppendInt(buf, frac, 10)
+	buf = append(buf, '"')
+	stream.SetBuffer(buf)
+}
+
+func (*splunkTimeCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	var s string
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s = iter.ReadString()
+	case jsoniter.NumberValue:
+		s = string(iter.ReadNumber())
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadSplunkTime", `invalid JSON value`)
+		return time.Time{}
+	}
+	if s == "" {
+		return time.Time{}
+	}
+	msec, err := parseUnixMilliseconds(s)
+	if err != nil {
+		iter.ReportError("ReadSplunkTime", err.Error())
+		return time.Time{}
+	}
+	return UnixMilliseconds(msec)
+}
+
+// parseUnixMilliseconds parses a decimal seconds string with up to 3 fractional digits to milliseconds
+func parseUnixMilliseconds(s string) (int64, error) {
+	sec, frac := s, ""
+	if pos := strings.IndexByte(s, '.'); pos != -1 {
+		sec, frac = s[:pos], s[pos+1:]
+	}
+	if len(frac) > 3 {
+		return 0, errors.New("too many fractional digits")
+	}
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	var msec int64
+	for i := 0; i < 3; i++ {
+		msec *= 10
+		if i < len(frac) {
+			if !isDigit(frac[i]) {
+				return 0, errors.New("invalid fractional digits")
+			}
+			msec += int64(frac[i] - '0')
+		}
+	}
+	if strings.HasPrefix(sec, "-") {
+		return n*1000 - msec, nil
+	}
+	return n*1000 + msec, nil
+}
+
+// UnixSecondsNanoStringCodec decodes/encodes string seconds since UNIX epoch with up to 9 fractional digits
+// (ie `"1577923200.123456789"`).
+// The integer and fractional parts are parsed separately so that nanoseconds are reconstructed exactly,
+// which is not possible when going through a float64 like UnixSecondsCodec does.
+// It decodes only string JSON values and encodes always to a string with exactly 9 decimals.
+func UnixSecondsNanoStringCodec() TimeCodec {
+	return &unixSecondsNanoStringCodec{}
+}
+
+type unixSecondsNanoStringCodec struct{}
+
+func (*unixSecondsNanoStringCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	nsec := tm.UnixNano()
+	buf := stream.Buffer()
+	buf = append(buf, '"')
+	if nsec < 0 {
+		buf = append(buf, '-')
+		nsec = -nsec
+	}
+	buf = strconv.AppendInt(buf, nsec/int64(time.Second), 10)
+	buf = append(buf, '.')
+	frac := strconv.FormatInt(nsec%int64(time.Second), 10)
+	buf = append(buf, strings.Repeat("0", 9-len(frac))...)
+	buf = append(buf, frac...)
+	buf = append(buf, '"')
+	stream.SetBuffer(buf)
+}
+
+func (*unixSecondsNanoStringCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		nsec, err := parseUnixNanoseconds(s)
+		if err != nil {
+			iter.ReportError("ReadUnixSecondsNanoString", err.Error())
+			return time.Time{}
+		}
+		return time.Unix(0, nsec).UTC()
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadUnixSecondsNanoString", `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// parseUnixNanoseconds parses a decimal seconds string with up to 9 fractional digits to nanoseconds
+func parseUnixNanoseconds(s string) (int64, error) {
+	sec, frac := s, ""
+	if pos := strings.IndexByte(s, '.'); pos != -1 {
+		sec, frac = s[:pos], s[pos+1:]
+	}
+	if len(frac) > 9 {
+		return 0, errors.New("too many fractional digits")
+	}
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	var nsec int64
+	for i := 0; i < 9; i++ {
+		nsec *= 10
+		if i < len(frac) {
+			if !isDigit(frac[i]) {
+				return 0, errors.New("invalid fractional digits")
+			}
+			nsec += int64(frac[i] - '0')
+		}
+	}
+	if strings.HasPrefix(sec, "-") {
+		return n*int64(time.Second) - nsec, nil
+	}
+	return n*int64(time.Second) + nsec, nil
+}
+
+// WordUnitEpochCodec decodes epoch timestamps followed by a unit word (ie `"1577923200 seconds"`, `"1577923200000 ms"`).
+// Supported unit words are `s`/`seconds`, `ms`/`milliseconds`, `us`/`microseconds` and `ns`/`nanoseconds`.
+// Values without a unit word are decoded as seconds.
+// It encodes to seconds since UNIX epoch without the unit word, like UnixSecondsCodec.
+func WordUnitEpochCodec() TimeCodec {
+	return &wordUnitEpochCodec{
+		seconds: UnixSecondsCodec(),
+	}
+}
+
+type wordUnitEpochCodec struct {
+	seconds TimeCodec
+}
+
+var epochUnitWords = map[string]time.Duration{
+	"s":            time.Second,
+	"sec":          time.Second,
+	"second":       time.Second,
+	"seconds":      time.Second,
+	"ms":           time.Millisecond,
+	"msec":         time.Millisecond,
+	"millisecond":  time.Millisecond,
+	"milliseconds": time.Millisecond,
+	"us":           time.Microsecond,
+	"usec":         time.Microsecond,
+	"microsecond":  time.Microsecond,
+	"microseconds": time.Microsecond,
+	"ns":           time.Nanosecond,
+	"nsec":         time.Nanosecond,
+	"nanosecond":   time.Nanosecond,
+	"nanoseconds":  time.Nanosecond,
+}
+
+func (c *wordUnitEpochCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	c.seconds.EncodeTime(tm, stream)
+}
+
+func (c *wordUnitEpochCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	if iter.WhatIsNext() != jsoniter.StringValue {
+		return c.seconds.DecodeTime(iter)
+	}
+	s := strings.TrimSpace(iter.ReadString())
+	if s == "" {
+		return time.Time{}
+	}
+	unit := time.Second
+	if pos := strings.IndexByte(s, ' '); pos != -1 {
+		word := strings.ToLower(strings.TrimSpace(s[pos+1:]))
+		u, ok := epochUnitWords[word]
+		if !ok {
+			iter.ReportError("ReadWordUnitEpoch", "unknown unit "+word)
+			return time.Time{}
+		}
+		s, unit = s[:pos], u
+	}
+	tm, err := parseEpoch(s, unit)
+	if err != nil {
+		iter.ReportError("ReadWordUnitEpoch", err.Error())
+		return time.Time{}
+	}
+	return tm
+}
+
+// parseEpoch parses an integer or decimal epoch value in `unit` since UNIX epoch
+func parseEpoch(s string, unit time.Duration) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return unixUnitTime(n, unit), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return UnixSeconds(f * unit.Seconds()), nil
+}
+
+// unixUnitTime converts an integer `n` of `unit` since UNIX epoch to time.Time avoiding overflows
+func unixUnitTime(n int64, unit time.Duration) time.Time {
+	if unit >= time.Second {
+		return time.Unix(n*int64(unit/time.Second), 0)
+	}
+	perSecond := int64(time.Second / unit)
+	return time.Unix(n/perSecond, (n%perSecond)*int64(unit))
+}
+
+// NumericPassthroughCodec decodes numeric timestamps in `unit` since UNIX epoch and re-encodes them in the same
+// number format they were received (ie `1577923200` stays an integer and `1577923200.0` stays a float).
+// Decoded timestamps are in UTC and the number format is kept in their time.Location.
+// Any location change resets it to integer, including `In(time.UTC, ...)` and calling `.UTC()` on the result,
+// so it is only byte-stable if the decoded time reaches EncodeTime unmodified.
+// Float timestamps are not `==` to their UTC counterparts, use `time.Time.Equal` to compare them.
+// It decodes both string and number JSON values and encodes always to number.
+func NumericPassthroughCodec(unit time.Duration) TimeCodec {
+	if unit <= 0 {
+		unit = time.Second
+	}
+	return &numericPassthroughCodec{
+		unit: unit,
+	}
+}
+
+// floatEpochUTC marks timestamps that were decoded from a float number.
+// It is a distinct *time.Location with zero offset, named so that it is not mistaken for time.UTC.
+var floatEpochUTC = time.FixedZone("UTC(float)", 0)
+
+type numericPassthroughCodec struct {
+	unit time.Duration
+}
+
+func (c *numericPassthroughCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	nsec := tm.UnixNano()
+	if tm.Location() != floatEpochUTC && nsec%int64(c.unit) == 0 {
+		stream.WriteInt64(nsec / int64(c.unit))
+		return
+	}
+	stream.SetBuffer(appendEpochFloat(stream.Buffer(), nsec, c.unit))
+}
+
+// appendEpochFloat appends `nsec` in `unit` as a decimal number that always has a fraction.
+// Units that are a power of 10 nanoseconds are formatted exactly to avoid float rounding errors.
+func appendEpochFloat(buf []byte, nsec int64, unit time.Duration) []byte {
+	scale, digits := int64(1), 0
+	for scale < int64(unit) {
+		scale *= 10
+		digits++
+	}
+	if scale != int64(unit) {
+		start := len(buf)
+		buf = strconv.AppendFloat(buf, float64(nsec)/float64(unit), 'f', -1, 64)
+		if bytes.IndexByte(buf[start:], '.') == -1 {
+			buf = append(buf, '.', '0')
+		}
+		return buf
+	}
+	if nsec < 0 {
+		buf = append(buf, '-')
+		nsec = -nsec
+	}
+	buf = strconv.AppendInt(buf, nsec/scale, 10)
+	if digits == 0 {
+		return append(buf, '.', '0')
+	}
+	buf = append(buf, '.')
+	frac := strconv.FormatInt(nsec%scale, 10)
+	frac = strings.Repeat("0", digits-len(frac)) + frac
+	if frac = strings.TrimRight(frac, "0"); frac == "" {
+		frac = "0"
+	}
+	return append(buf, frac...)
+}
+
+func (c *numericPassthroughCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	var s string
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		s = string(iter.ReadNumber())
+	case jsoniter.StringValue:
+		s = iter.ReadString()
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadNumericEpoch", `invalid JSON value`)
+		return time.Time{}
+	}
+	if s == "" {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return unixUnitTime(n, c.unit).UTC()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		iter.ReportError("ReadNumericEpoch", err.Error())
+		return time.Time{}
+	}
+	return UnixSeconds(f * c.unit.Seconds()).In(floatEpochUTC)
+}
+
+// MinutesSinceMidnightCodec decodes timestamps expressed as integer minutes since midnight (ie `905` is 15:05)
+// on the date returned by `date` (in the location of that date).
+// Values of 1440 or more wrap over to the following days (ie `1440` is midnight of the next day).
+// It encodes the minutes since midnight of a timestamp, so the date part is lost.
+// It decodes both string and number JSON values and encodes always to number.
+func MinutesSinceMidnightCodec(date func() time.Time) TimeCodec {
+	if date == nil {
+		date = time.Now
+	}
+	return &minutesSinceMidnightCodec{
+		date: date,
+	}
+}
+
+type minutesSinceMidnightCodec struct {
+	date func() time.Time
+}
+
+func (c *minutesSinceMidnightCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteInt(tm.Hour()*60 + tm.Minute())
+}
+
+func (c *minutesSinceMidnightCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	var minutes int64
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		minutes = iter.ReadInt64()
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			iter.ReportError("ReadMinutesSinceMidnight", err.Error())
+			return time.Time{}
+		}
+		minutes = n
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadMinutesSinceMidnight", `invalid JSON value`)
+		return time.Time{}
+	}
+	if minutes < 0 {
+		iter.ReportError("ReadMinutesSinceMidnight", "negative minutes since midnight")
+		return time.Time{}
+	}
+	date := c.date()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, int(minutes), 0, 0, date.Location())
+}
+
+// UnixCutoverCodec decodes integer epoch timestamps from feeds that switched from seconds to milliseconds
+// in the same field at some point in time.
+// The unit is picked using a single threshold: values with an absolute value below `cutoverMagnitude` are
+// seconds and values at or above it are milliseconds.
+// Unlike auto-detection the threshold is fixed, so it should be chosen from the known cutover date of the feed
+// (ie `1e11` treats everything up to `99999999999` as seconds, which is the year 5138 in seconds and
+// 1973-03-03 in milliseconds).
+// It decodes both string and number JSON values and encodes always to number in milliseconds.
+func UnixCutoverCodec(cutoverMagnitude int64) TimeCodec {
+	return &unixCutoverCodec{
+		cutover: cutoverMagnitude,
+	}
+}
+
+type unixCutoverCodec struct {
+	cutover int64
+}
+
+func (*unixCutoverCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteInt64(tm.UnixNano() / int64(time.Millisecond))
+}
+
+func (c *unixCutoverCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	var n int64
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		n = iter.ReadInt64()
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			iter.ReportError("ReadUnixCutover", err.Error())
+			return time.Time{}
+		}
+		n = v
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadUnixCutover", `invalid JSON value`)
+		return time.Time{}
+	}
+	if n < c.cutover && n > -c.cutover {
+		return time.Unix(n, 0).UTC()
+	}
+	return UnixMilliseconds(n).UTC()
+}
+
+// Seconds between the Windows FILETIME epoch (1601-01-01) and the UNIX epoch
+const fileTimeEpochOffset = 11644473600
+
+// Number of 100-nanosecond FILETIME intervals per second
+const fileTimePerSecond = int64(time.Second / 100)
+
+// FileTime reads a timestamp from a Windows FILETIME value (100-nanosecond intervals since 1601-01-01 UTC).
+func FileTime(n int64) time.Time {
+	return time.Unix(n/fileTimePerSecond-fileTimeEpochOffset, (n%fileTimePerSecond)*100).UTC()
+}
+
+// FileTimeCodec decodes/encodes Windows FILETIME timestamps (100-nanosecond intervals since 1601-01-01 UTC).
+// A FILETIME of `0` decodes to 1601-01-01 and not to zero time.
+// Sub-100ns precision is truncated when encoding.
+// It decodes both string and number JSON values and encodes always to number.
+func FileTimeCodec() TimeCodec {
+	return &fileTimeCodec{}
+}
+
+type fileTimeCodec struct{}
+
+func (*fileTimeCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	n := (tm.Unix()+fileTimeEpochOffset)*fileTimePerSecond + int64(tm.Nanosecond()/100)
+	stream.WriteInt64(n)
+}
+
+func (*fileTimeCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		return FileTime(iter.ReadInt64())
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			iter.ReportError("ReadFileTime", err.Error())
+			return time.Time{}
+		}
+		return FileTime(n)
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadFileTime", `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// RelativeCodec decodes timestamps expressed as a duration offset from the time returned by `now`
+// (ie `"-5m"` or `"-1h30m"`). Offsets use the time.ParseDuration format.
+// If `now` is nil, time.Now is used.
+// It decodes only string JSON values and encodes to an RFC3339 string, so the offset is lost.
+func RelativeCodec(now func() time.Time) TimeCodec {
+	if now == nil {
+		now = time.Now
+	}
+	return &relativeCodec{
+		now: now,
+	}
+}
+
+type relativeCodec struct {
+	now func() time.Time
+}
+
+func (*relativeCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteString(tm.Format(time.RFC3339Nano))
+}
+
+func (c *relativeCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			iter.ReportError("ReadRelativeTime", err.Error())
+			return time.Time{}
+		}
+		return c.now().Add(d)
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadRelativeTime", `invalid JSON value`)
+		return time.Time{}
+	}
+}
@@ -0,0 +1,402 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplunkTimeCodec(t *testing.T) {
+	codec := SplunkTimeCodec()
+	for _, input := range []string{
+		`"1577923200.001"`,
+		`"1577923200.999"`,
+		`"1577923200.000"`,
+		`"1577923200.010"`,
+		`"-1.500"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(tm, stream)
+		require.Equal(t, input, string(stream.Buffer()), "round-trip")
+	}
+
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.999"`)
+	tm := codec.DecodeTime(iter)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, int(999*time.Millisecond), time.UTC), tm.UTC())
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.5"`)
+	tm = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, int(500*time.Millisecond), time.UTC), tm.UTC())
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200.001 `)
+	tm = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, int(time.Millisecond), time.UTC), tm.UTC())
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.0001"`)
+	_ = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"foo"`)
+	_ = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(time.Time{}, stream)
+	require.Equal(t, `null`, string(stream.Buffer()))
+}
+
+func TestWordUnitEpochCodec(t *testing.T) {
+	codec := WordUnitEpochCodec()
+	expect := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{
+		`"1577923200"`,
+		`1577923200 `,
+		`"1577923200 s"`,
+		`"1577923200 seconds"`,
+		`"1577923200000 ms"`,
+		`"1577923200000 milliseconds"`,
+		`"1577923200000000 us"`,
+		`"1577923200000000000 ns"`,
+		`" 1577923200  Seconds "`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual.UTC(), input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.5 seconds"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect.Add(500*time.Millisecond), actual.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200 fortnights"`)
+		_ = codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(expect, stream)
+		require.Equal(t, `1577923200`, string(stream.Buffer()))
+	}
+}
+
+func TestNumericPassthroughCodec(t *testing.T) {
+	codec := NumericPassthroughCodec(time.Second)
+	for input, expect := range map[string]time.Time{
+		`1577923200`:       time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		`1577923200.0`:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		`1577923200.5`:     time.Date(2020, 1, 2, 0, 0, 0, int(500*time.Millisecond), time.UTC),
+		`1577923200.123`:   time.Date(2020, 1, 2, 0, 0, 0, int(123*time.Millisecond), time.UTC),
+		`-1.5`:             time.Date(1969, 12, 31, 23, 59, 58, int(500*time.Millisecond), time.UTC),
+		`"1577923200"`:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		`"1577923200.250"`: time.Date(2020, 1, 2, 0, 0, 0, int(250*time.Millisecond), time.UTC),
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input+` `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, expect.Equal(actual), "%s %s", input, actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		switch input {
+		case `"1577923200"`:
+			require.Equal(t, `1577923200`, string(stream.Buffer()))
+		case `"1577923200.250"`:
+			require.Equal(t, `1577923200.25`, string(stream.Buffer()))
+		default:
+			require.Equal(t, input, string(stream.Buffer()))
+		}
+	}
+	{
+		codec := NumericPassthroughCodec(time.Millisecond)
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200123 `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, int(123*time.Millisecond), time.UTC), actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `1577923200123`, string(stream.Buffer()))
+	}
+	{
+		codec := NumericPassthroughCodec(time.Nanosecond)
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200000000001.0 `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `1577923200000000000.0`, string(stream.Buffer()))
+	}
+	{
+		// Times not decoded by the codec encode as integers unless they have a fraction
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), stream)
+		require.Equal(t, `1577923200`, string(stream.Buffer()))
+		stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"foo"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		// The float format does not survive a location change
+		codec := In(time.UTC, NumericPassthroughCodec(time.Second))
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200.0 `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `1577923200`, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200.0 `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.NotEqual(t, time.UTC, actual.Location())
+		require.True(t, actual.Equal(actual.UTC()))
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual.UTC(), stream)
+		require.Equal(t, `1577923200`, string(stream.Buffer()))
+	}
+}
+
+func TestMinutesSinceMidnightCodec(t *testing.T) {
+	date := func() time.Time {
+		return time.Date(2020, 1, 2, 13, 14, 15, 0, time.UTC)
+	}
+	codec := MinutesSinceMidnightCodec(date)
+	for input, expect := range map[string]time.Time{
+		`0`:      time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		`905`:    time.Date(2020, 1, 2, 15, 5, 0, 0, time.UTC),
+		`"905"`:  time.Date(2020, 1, 2, 15, 5, 0, 0, time.UTC),
+		`1440`:   time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC),
+		`"1439"`: time.Date(2020, 1, 2, 23, 59, 0, 0, time.UTC),
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input+` `)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual, input)
+	}
+	for tm, expect := range map[time.Time]string{
+		time.Date(2020, 1, 2, 15, 5, 0, 0, time.UTC): `905`,
+		time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC):  `0`,
+		{}: `null`,
+	} {
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(tm, stream)
+		require.Equal(t, expect, string(stream.Buffer()))
+	}
+	for _, input := range []string{`-1 `, `"15:05"`, `[]`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+}
+
+func TestUnixSecondsNanoStringCodec(t *testing.T) {
+	codec := UnixSecondsNanoStringCodec()
+	for _, input := range []string{
+		`"1577923200.123456789"`,
+		`"1577923200.000000001"`,
+		`"1577923200.999999999"`,
+		`"1577923200.000000000"`,
+		`"-1.500000000"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(tm, stream)
+		require.Equal(t, input, string(stream.Buffer()), "round-trip")
+	}
+
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.123456789"`)
+	tm := codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 123456789, time.UTC), tm)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.5"`)
+	tm = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, int(500*time.Millisecond), time.UTC), tm)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200"`)
+	tm = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), tm)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"-0.5"`)
+	tm = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Unix(0, -int64(500*time.Millisecond)).UTC(), tm)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.1234567891"`)
+	codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"1577923200.12a"`)
+	codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200.123456789`)
+	tm = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+	require.True(t, tm.IsZero())
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `null`)
+	tm = codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.True(t, tm.IsZero())
+}
+
+func TestUnixCutoverCodec(t *testing.T) {
+	const cutover = 1e11
+	codec := UnixCutoverCodec(cutover)
+	for _, tc := range []struct {
+		input  string
+		expect time.Time
+	}{
+		{`1577923200 `, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`"1577923200"`, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`1577923200001 `, time.Date(2020, 1, 2, 0, 0, 0, int(time.Millisecond), time.UTC)},
+		{`99999999999 `, time.Unix(99999999999, 0).UTC()},
+		{`100000000000 `, time.Unix(100000000, 0).UTC()},
+		{`-99999999999 `, time.Unix(-99999999999, 0).UTC()},
+		{`-100000000000 `, time.Unix(-100000000, 0).UTC()},
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, tc.input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, tc.input)
+		require.Equal(t, tc.expect, tm, tc.input)
+	}
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(time.Date(2020, 1, 2, 0, 0, 0, int(time.Millisecond), time.UTC), stream)
+	require.Equal(t, `1577923200001`, string(stream.Buffer()))
+
+	stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(time.Time{}, stream)
+	require.Equal(t, `null`, string(stream.Buffer()))
+
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `""`)
+	require.True(t, codec.DecodeTime(iter).IsZero())
+	require.NoError(t, iter.Error)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"foo"`)
+	_ = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `{}`)
+	_ = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+}
+
+func TestFileTimeCodec(t *testing.T) {
+	codec := FileTimeCodec()
+	for _, tc := range []struct {
+		input  string
+		expect string
+	}{
+		{`132223104000000000`, "2020-01-01T00:00:00Z"},
+		{`132223104001234567`, "2020-01-01T00:00:00.1234567Z"},
+		{`116444736000000000`, "1970-01-01T00:00:00Z"},
+		{`0`, "1601-01-01T00:00:00Z"},
+		{`130000000000000000`, "2012-12-14T23:06:40Z"},
+	} {
+		expect, err := time.Parse(time.RFC3339Nano, tc.expect)
+		require.NoError(t, err)
+		require.Equal(t, expect, FileTime(mustParseInt(t, tc.input)), tc.input)
+
+		for _, input := range []string{tc.input + ` `, `"` + tc.input + `"`} {
+			iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+			tm := codec.DecodeTime(iter)
+			require.NoError(t, iter.Error, input)
+			require.Equal(t, expect, tm, input)
+		}
+
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(expect, stream)
+		require.Equal(t, tc.input, string(stream.Buffer()))
+	}
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(time.Time{}, stream)
+	require.Equal(t, `null`, string(stream.Buffer()))
+
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"foo"`)
+	_ = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+}
+
+func mustParseInt(t *testing.T, s string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(s, 10, 64)
+	require.NoError(t, err)
+	return n
+}
+
+func TestRelativeCodec(t *testing.T) {
+	now := time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC)
+	codec := RelativeCodec(func() time.Time { return now })
+	for input, expect := range map[string]time.Time{
+		`"-5m"`:    now.Add(-5 * time.Minute),
+		`"-1h30m"`: now.Add(-90 * time.Minute),
+		`"0s"`:     now,
+		`"+10s"`:   now.Add(10 * time.Second),
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, tm, input)
+	}
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(now.Add(-5*time.Minute), stream)
+	require.Equal(t, `"2020-01-02T14:55:00Z"`, string(stream.Buffer()))
+
+	stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(time.Time{}, stream)
+	require.Equal(t, `null`, string(stream.Buffer()))
+
+	for _, input := range []string{`"5 minutes"`, `"-5"`, `300 `} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		_ = codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+
+	// Defaults to time.Now
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"-1h"`)
+	tm := RelativeCodec(nil).DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.WithinDuration(t, time.Now().Add(-time.Hour), tm, time.Minute)
+}
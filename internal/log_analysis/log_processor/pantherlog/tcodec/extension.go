@@ -19,7 +19,6 @@ package tcodec
  */
 
 import (
-	"fmt"
 	"reflect"
 	"strings"
 	"time"
@@ -45,6 +44,11 @@ import (
 // }
 // ```
 //
+// Parametric names like `unix:ms`, `layout:2006-01-02` or `in:UTC:unix` are also resolved (see Registry).
+//
+// An `&Extension{}` using the default registry is registered globally on init.
+// To isolate codec behavior per jsoniter.API create an Extension with custom options and use RegisterTo.
+//
 type Extension struct {
 	jsoniter.DummyExtension
 
@@ -54,6 +58,28 @@ type Extension struct {
 	// TagName sets the struct tag name to use for tcodec options.
 	// If this option is not set the `DefaultTagName` will be used.
 	TagName string
+
+	config Config
+}
+
+// Config holds the options of an Extension created with NewExtension.
+type Config struct {
+	// DefaultCodec is used for time.Time fields without a `tcodec` tag.
+	// If this option is `nil` those fields are left to the default time.Time JSON handling (see StdCodec).
+	DefaultCodec TimeCodec
+}
+
+// NewExtension creates an Extension using `config`.
+func NewExtension(config Config) *Extension {
+	return &Extension{
+		config: config,
+	}
+}
+
+// RegisterTo registers the extension to a specific jsoniter.API instead of the global jsoniter registry.
+func (ext *Extension) RegisterTo(api jsoniter.API) jsoniter.API {
+	api.RegisterExtension(ext)
+	return api
 }
 
 // DefaultTagName is the struct tag name used for defining time decoders for a time.Time field.
@@ -64,6 +90,28 @@ var (
 	typTimePtr = reflect.PtrTo(typTime)
 )
 
+// CreateDecoder implements jsoniter.Extension.
+// Values of type time.Time that are not struct fields with a tag (ie top-level values or slice elements)
+// are decoded using Config.DefaultCodec. If no default codec is set they are left to the default handling.
+func (ext *Extension) CreateDecoder(typ reflect2.Type) jsoniter.ValDecoder {
+	if typ := typ.Type1(); typ == typTime && ext.config.DefaultCodec != nil {
+		return NewTimeDecoder(ext.config.DefaultCodec, typ)
+	}
+	return nil
+}
+
+// CreateEncoder implements jsoniter.Extension.
+// Values of type time.Time that are not struct fields with a tag (ie top-level values or slice elements)
+// are encoded using Config.DefaultCodec. If no default codec is set they are left to the default handling.
+func (ext *Extension) CreateEncoder(typ reflect2.Type) jsoniter.ValEncoder {
+	if typ := typ.Type1(); typ == typTime && ext.config.DefaultCodec != nil {
+		return NewTimeEncoder(ext.config.DefaultCodec, typ)
+	}
+	return nil
+}
+
+// UpdateStructDescriptor implements jsoniter.Extension.
+// It resolves the TimeCodec of time.Time and *time.Time struct fields from their struct tag.
 func (ext *Extension) UpdateStructDescriptor(desc *jsoniter.StructDescriptor) {
 	tagName := ext.tagName()
 	for _, binding := range desc.Fields {
@@ -76,20 +124,22 @@ func (ext *Extension) UpdateStructDescriptor(desc *jsoniter.StructDescriptor) {
 			// We only affect time.Time and *time.Time fields
 			continue
 		}
-		tag, ok := field.Tag().Lookup(tagName)
-		if !ok {
-			// We only affect fields that have the tag
-			continue
-		}
-		// convert tag to TimeCodec
-		codec, err := ext.resolveCodec(tag)
-		if err != nil {
-			// Report failed lookup error on decode/encode
-			jsonCodec := &errCodec{
-				err:       err,
-				operation: "LookupTimeCodec",
+		var codec TimeCodec
+		if tag, ok := field.Tag().Lookup(tagName); ok {
+			// convert tag to TimeCodec
+			c, err := ext.ResolveCodec(tag)
+			if err != nil {
+				// Report failed lookup error on decode/encode
+				jsonCodec := &errCodec{
+					err:       err,
+					operation: "LookupTimeCodec",
+				}
+				binding.Decoder, binding.Encoder = jsonCodec, jsonCodec
+				continue
 			}
-			binding.Decoder, binding.Encoder = jsonCodec, jsonCodec
+			codec = c
+		} else if codec = ext.config.DefaultCodec; codec == nil {
+			// We only affect fields that have the tag unless there is a default codec
 			continue
 		}
 
@@ -118,7 +168,10 @@ type EncoderDecorator interface {
 	DecorateEncoder(typ reflect2.Type, dec jsoniter.ValEncoder) jsoniter.ValEncoder
 }
 
-func (ext *Extension) resolveCodec(tag string) (TimeCodec, error) {
+// ResolveCodec resolves the TimeCodec for a struct tag value.
+// Tags of the form `layout=GO_TIME_LAYOUT` are resolved to a LayoutCodec, all other tags are resolved
+// using the Codecs registry of the extension, falling back to the default registry.
+func (ext *Extension) ResolveCodec(tag string) (TimeCodec, error) {
 	// NOTE: [tcodec] Add support for other layout types such as strftime (https://strftime.org/)
 	if strings.HasPrefix(tag, "layout=") {
 		// The tag is of the form `layout=GO_TIME_LAYOUT`.
@@ -131,10 +184,7 @@ func (ext *Extension) resolveCodec(tag string) (TimeCodec, error) {
 			return codec, nil
 		}
 	}
-	if codec := Lookup(tag); codec != nil {
-		return codec, nil
-	}
-	return nil, fmt.Errorf(`failed to resolve %q time codec`, tag)
+	return Resolve(tag)
 }
 
 func (ext *Extension) tagName() string {
@@ -174,6 +224,25 @@ func (enc *jsonTimePtrEncoder) Encode(ptr unsafe.Pointer, stream *jsoniter.Strea
 	}
 }
 
+// jsonTimeOmitZeroEncoder omits zero time struct fields for ZeroOmit.
+// jsoniter only checks IsEmpty for fields tagged with `omitempty` so we hook into IsEmbeddedPtrNil
+// which is checked for all struct fields.
+type jsonTimeOmitZeroEncoder struct {
+	jsonTimeEncoder
+}
+
+func (enc *jsonTimeOmitZeroEncoder) IsEmbeddedPtrNil(ptr unsafe.Pointer) bool {
+	return enc.IsEmpty(ptr)
+}
+
+type jsonTimePtrOmitZeroEncoder struct {
+	jsonTimePtrEncoder
+}
+
+func (enc *jsonTimePtrOmitZeroEncoder) IsEmbeddedPtrNil(ptr unsafe.Pointer) bool {
+	return enc.IsEmpty(ptr)
+}
+
 type jsonTimeDecoder struct {
 	decode TimeDecoderFunc
 }
@@ -187,7 +256,13 @@ type jsonTimePtrDecoder struct {
 	typ    reflect.Type
 }
 
+// Decode sets the pointer to nil on JSON `null` and only allocates a new time.Time for non-zero timestamps.
+// The TimeDecoder is not called for `null`, so a nil pointer always means the value was absent.
 func (dec *jsonTimePtrDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	if iter.ReadNil() {
+		*(**time.Time)(ptr) = nil
+		return
+	}
 	tm := dec.decode(iter)
 	pt := *(**time.Time)(ptr)
 	if pt != nil {
@@ -31,6 +31,7 @@ func TestNewExtension(t *testing.T) {
 	type T struct {
 		TimeRFC3339 time.Time `json:"t_rfc,omitempty" tcodec:"rfc3339"`
 		TimeUnixMS  time.Time `json:"t_unix_ms,omitempty" tcodec:"unix_ms"`
+		TimeUnixNS  time.Time `json:"t_unix_ns,omitempty" tcodec:"unix_ns"`
 		TimeUnix    time.Time `json:"t_unix,omitempty" tcodec:"unix"`
 		TimeCustom  time.Time `json:"t_custom,omitempty" tcodec:"layout=2006-01-02"`
 		Time        time.Time `json:"t,omitempty"`
@@ -44,12 +45,14 @@ func TestNewExtension(t *testing.T) {
 		"t_rfc": "%s",
 		"t_custom": "%s",
 		"t_unix": "%f",
-		"t_unix_ms": "%d"
+		"t_unix_ms": "%d",
+		"t_unix_ns": %d
 	}`,
 		tm.Format(time.RFC3339Nano),
 		tm.Format("2006-01-02"),
 		time.Duration(tm.UnixNano()).Seconds(),
 		time.Duration(tm.UnixNano()).Milliseconds(),
+		tm.UnixNano(),
 	)
 	actual := T{}
 	err := api.UnmarshalFromString(input, &actual)
@@ -59,6 +62,7 @@ func TestNewExtension(t *testing.T) {
 	require.Equal(t, expect, actual.TimeRFC3339.UTC().Format(time.RFC3339Nano), "rfc3339")
 	require.Equal(t, expect, actual.TimeUnix.UTC().Format(time.RFC3339Nano), "unix")
 	require.Equal(t, expect, actual.TimeUnixMS.UTC().Format(time.RFC3339Nano), "unix_ms")
+	require.Equal(t, expect, actual.TimeUnixNS.UTC().Format(time.RFC3339Nano), "unix_ns")
 }
 
 func TestPointerZeroValues(t *testing.T) {
@@ -116,3 +120,117 @@ func TestPointerZeroValues(t *testing.T) {
 		require.Equal(t, `{"tm":null}`, actual)
 	}
 }
+
+func TestExtensionParametricTags(t *testing.T) {
+	type T struct {
+		TimeMS     time.Time `json:"t_ms" tcodec:"unix:ms"`
+		TimeLayout time.Time `json:"t_layout" tcodec:"layout:2006-01-02 15:04"`
+		TimeBad    time.Time `json:"t_bad,omitempty" tcodec:"unix:days"`
+	}
+	api := jsoniter.Config{}.Froze()
+	api.RegisterExtension(&Extension{})
+
+	actual := T{}
+	require.NoError(t, api.UnmarshalFromString(`{"t_ms":1601562774569,"t_layout":"2020-10-01 14:32"}`, &actual))
+	require.Equal(t, time.Date(2020, 10, 1, 14, 32, 54, 569*int(time.Millisecond), time.UTC), actual.TimeMS.UTC())
+	require.Equal(t, time.Date(2020, 10, 1, 14, 32, 0, 0, time.UTC), actual.TimeLayout.UTC())
+	require.Error(t, api.UnmarshalFromString(`{"t_bad":1}`, &actual))
+}
+
+func TestExtensionDefaultCodec(t *testing.T) {
+	type T struct {
+		Time       time.Time  `json:"t"`
+		TimePtr    *time.Time `json:"t_ptr"`
+		TimeLayout time.Time  `json:"t_layout" tcodec:"layout=2006-01-02"`
+	}
+	api := jsoniter.Config{}.Froze()
+	api.RegisterExtension(NewExtension(Config{
+		DefaultCodec: UnixMillisecondsCodec(),
+	}))
+	tm := time.Date(2020, 10, 1, 14, 32, 54, 569*int(time.Millisecond), time.UTC)
+	actual := T{}
+	input := `{"t":1601562774569,"t_ptr":1601562774569,"t_layout":"2020-10-01"}`
+	require.NoError(t, api.UnmarshalFromString(input, &actual))
+	require.Equal(t, tm, actual.Time.UTC())
+	require.NotNil(t, actual.TimePtr)
+	require.Equal(t, tm, actual.TimePtr.UTC())
+	require.Equal(t, time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC), actual.TimeLayout.UTC())
+	output, err := api.MarshalToString(&actual)
+	require.NoError(t, err)
+	require.Equal(t, input, output)
+
+	// Without a default codec untagged fields use the standard time.Time JSON format
+	api = jsoniter.Config{}.Froze()
+	api.RegisterExtension(NewExtension(Config{}))
+	actual = T{}
+	input = `{"t":"2020-10-01T14:32:54.569Z","t_ptr":null,"t_layout":"2020-10-01"}`
+	require.NoError(t, api.UnmarshalFromString(input, &actual))
+	require.Equal(t, tm, actual.Time.UTC())
+	require.Nil(t, actual.TimePtr)
+	output, err = api.MarshalToString(&actual)
+	require.NoError(t, err)
+	require.Equal(t, input, output)
+}
+
+func TestExtensionRegisterTo(t *testing.T) {
+	ext := NewExtension(Config{
+		DefaultCodec: UnixSecondsCodec(),
+	})
+	api := ext.RegisterTo(jsoniter.Config{}.Froze())
+	tm := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// Values that are not struct fields use the default codec
+	output, err := api.MarshalToString([]time.Time{tm})
+	require.NoError(t, err)
+	require.Equal(t, `[1577923200]`, output)
+	var actual []time.Time
+	require.NoError(t, api.UnmarshalFromString(`[1577923200]`, &actual))
+	require.Len(t, actual, 1)
+	require.Equal(t, tm, actual[0].UTC())
+
+	// Other APIs are not affected
+	output, err = jsoniter.ConfigDefault.MarshalToString([]time.Time{tm})
+	require.NoError(t, err)
+	require.Equal(t, `["2020-01-02T00:00:00Z"]`, output)
+}
+
+func TestExtensionResolveCodec(t *testing.T) {
+	ext := &Extension{}
+	codec, err := ext.ResolveCodec("layout=2006-01-02")
+	require.NoError(t, err)
+	require.Equal(t, LayoutCodec("2006-01-02"), codec)
+	codec, err = ext.ResolveCodec("unix_ms")
+	require.NoError(t, err)
+	require.NotNil(t, codec)
+	_, err = ext.ResolveCodec("unix:days")
+	require.Error(t, err)
+}
+
+func TestPointerNilRoundTrip(t *testing.T) {
+	api := jsoniter.Config{}.Froze()
+	api.RegisterExtension(&Extension{})
+	type T struct {
+		Time *time.Time `json:"tm" tcodec:"unix_ms"`
+	}
+	output, err := api.MarshalToString(T{})
+	require.NoError(t, err)
+	require.Equal(t, `{"tm":null}`, output)
+	actual := T{}
+	require.NoError(t, api.UnmarshalFromString(output, &actual))
+	require.Nil(t, actual.Time)
+
+	// A previously set pointer is reset on null
+	tm := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	actual = T{Time: &tm}
+	require.NoError(t, api.UnmarshalFromString(`{"tm":null}`, &actual))
+	require.Nil(t, actual.Time)
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), tm, "the previous value is not modified")
+
+	// The UNIX epoch is a valid timestamp and not an absent value
+	require.NoError(t, api.UnmarshalFromString(`{"tm":0}`, &actual))
+	require.NotNil(t, actual.Time)
+	require.Equal(t, time.Unix(0, 0).UTC(), actual.Time.UTC())
+	output, err = api.MarshalToString(actual)
+	require.NoError(t, err)
+	require.Equal(t, `{"tm":0}`, output)
+}
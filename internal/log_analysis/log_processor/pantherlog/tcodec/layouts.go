@@ -0,0 +1,523 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// ISO8601CommaFractionCodec decodes RFC3339 timestamps using either `.` or `,` as the fractional seconds separator
+// (ie `2020-01-02T15:04:05,123Z`) as allowed by ISO 8601.
+// It encodes timestamps like StdCodec, always using `.` as separator.
+func ISO8601CommaFractionCodec() TimeCodec {
+	return &iso8601CommaFractionCodec{}
+}
+
+type iso8601CommaFractionCodec struct {
+	stdCodec
+}
+
+func (*iso8601CommaFractionCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		tm, err := time.Parse(time.RFC3339Nano, normalizeCommaFraction(s))
+		if err != nil {
+			iter.ReportError(`DecodeTime`, err.Error())
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError(`DecodeTime`, `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// normalizeCommaFraction replaces a comma between the seconds and the fractional digits with a dot.
+func normalizeCommaFraction(s string) string {
+	pos := strings.IndexByte(s, ',')
+	if 0 < pos && pos+1 < len(s) && isDigit(s[pos-1]) && isDigit(s[pos+1]) {
+		return s[:pos] + "." + s[pos+1:]
+	}
+	return s
+}
+
+// AssumeUTCIfNoZoneCodec decodes timestamps using a zone-less `layout` (ie `2006-01-02T15:04:05`).
+// Values without a zone are decoded as UTC, values ending with a zone indicator (`Z`, `+07:00` or `+0700`)
+// are decoded using that offset.
+// It encodes timestamps in UTC using RFC3339.
+func AssumeUTCIfNoZoneCodec(layout string) TimeCodec {
+	return &assumeUTCCodec{
+		layout: layout,
+	}
+}
+
+type assumeUTCCodec struct {
+	layout string
+}
+
+func (c *assumeUTCCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteString(tm.UTC().Format(time.RFC3339Nano))
+}
+
+func (c *assumeUTCCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		tm, err := time.ParseInLocation(c.layout+zoneLayout(s), s, time.UTC)
+		if err != nil {
+			iter.ReportError(`DecodeTime`, err.Error())
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError(`DecodeTime`, `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// zoneLayout returns the layout of the zone indicator at the end of `s` or an empty string if there is none.
+func zoneLayout(s string) string {
+	n := len(s)
+	switch {
+	case n > 0 && s[n-1] == 'Z':
+		return "Z07:00"
+	case n > 6 && isZoneSign(s[n-6]) && isDigit(s[n-5]) && isDigit(s[n-4]) && s[n-3] == ':' &&
+		isDigit(s[n-2]) && isDigit(s[n-1]):
+		return "Z07:00"
+	case n > 5 && isZoneSign(s[n-5]) && isDigit(s[n-4]) && isDigit(s[n-3]) && isDigit(s[n-2]) && isDigit(s[n-1]):
+		return "Z0700"
+	default:
+		return ""
+	}
+}
+
+func isZoneSign(c byte) bool {
+	return c == '+' || c == '-'
+}
+
+// ISO 8601 basic format layouts (no `-` and `:` separators)
+const (
+	iso8601BasicLayout     = "20060102T150405Z0700"
+	iso8601BasicNanoLayout = "20060102T150405.999999999Z0700"
+)
+
+// ISO8601BasicCodec decodes/encodes timestamps in ISO 8601 basic format (ie `20200102T150405Z`).
+// It decodes optional fractional seconds and a `Z` or `+hhmm` zone offset (ie `20200102T150405.123+0500`).
+// It encodes timestamps in basic format keeping their offset and fractional seconds if any.
+func ISO8601BasicCodec() TimeCodec {
+	return &iso8601BasicCodec{}
+}
+
+type iso8601BasicCodec struct{}
+
+func (*iso8601BasicCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteString(tm.Format(iso8601BasicNanoLayout))
+}
+
+func (*iso8601BasicCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		// Fractional seconds are accepted by time.Parse even if the layout does not include them
+		tm, err := time.Parse(iso8601BasicLayout, s)
+		if err != nil {
+			iter.ReportError(`DecodeTime`, err.Error())
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError(`DecodeTime`, `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// MySQL zero date sentinels, used instead of NULL for missing DATE and DATETIME values
+const (
+	mysqlZeroDate     = "0000-00-00"
+	mysqlZeroDateTime = "0000-00-00 00:00:00"
+)
+
+// MySQLZeroDateTolerantCodec decodes/encodes timestamps using `layout` in UTC,
+// mapping the MySQL zero date (ie `0000-00-00`, `0000-00-00 00:00:00`, `0000-00-00 00:00:00.000000`) to zero time.
+// It encodes zero time to the zero date sentinel, including the time part if `layout` has an hour field.
+func MySQLZeroDateTolerantCodec(layout string) TimeCodec {
+	zero := mysqlZeroDate
+	if strings.Contains(layout, "15") {
+		zero = mysqlZeroDateTime
+	}
+	return &mysqlZeroDateCodec{
+		layout: layout,
+		zero:   zero,
+	}
+}
+
+type mysqlZeroDateCodec struct {
+	layout string
+	zero   string
+}
+
+func (c *mysqlZeroDateCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteString(c.zero)
+		return
+	}
+	stream.WriteString(tm.UTC().Format(c.layout))
+}
+
+func (c *mysqlZeroDateCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" || isMySQLZeroDate(s) {
+			return time.Time{}
+		}
+		tm, err := time.ParseInLocation(c.layout, s, time.UTC)
+		if err != nil {
+			iter.ReportError(`DecodeTime`, err.Error())
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError(`DecodeTime`, `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// isMySQLZeroDate checks if `s` is the zero date optionally followed by an all-zeros time part.
+func isMySQLZeroDate(s string) bool {
+	if !strings.HasPrefix(s, mysqlZeroDate) {
+		return false
+	}
+	for i := len(mysqlZeroDate); i < len(s); i++ {
+		switch s[i] {
+		case '0', ':', '.', ' ', 'T':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// LayoutsCodec decodes timestamps trying each of the layouts in order and encodes them using the `primary` layout.
+// If no layout matches, a single error listing all attempted layouts is reported.
+func LayoutsCodec(primary string, fallbacks ...string) TimeCodec {
+	if len(fallbacks) == 0 {
+		return LayoutCodec(primary)
+	}
+	layouts := make([]string, 0, 1+len(fallbacks))
+	layouts = append(layouts, primary)
+	layouts = append(layouts, fallbacks...)
+	return &layoutsCodec{
+		layouts: layouts,
+	}
+}
+
+type layoutsCodec struct {
+	layouts []string
+}
+
+func (c *layoutsCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	stream.WriteString(tm.Format(c.layouts[0]))
+}
+
+func (c *layoutsCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		for _, layout := range c.layouts {
+			if tm, err := time.Parse(layout, s); err == nil {
+				return tm
+			}
+		}
+		iter.ReportError(`DecodeTime`, fmt.Sprintf("failed to parse %q using layouts %q", s, c.layouts))
+		return time.Time{}
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError(`DecodeTime`, `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+const layoutTimeOfDay = "15:04:05.999999999"
+
+// TimeOfDayCodec decodes bare time of day values (ie `15:04:05`, `15:04:05.123`) on the date returned by `ref`
+// (today if nil) in `loc` (UTC if nil).
+// It encodes the time of day in `loc`, with fractional seconds only if any, so the date part is lost.
+func TimeOfDayCodec(ref func() time.Time, loc *time.Location) TimeCodec {
+	if ref == nil {
+		ref = time.Now
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &timeOfDayCodec{
+		ref: ref,
+		loc: loc,
+	}
+}
+
+type timeOfDayCodec struct {
+	ref func() time.Time
+	loc *time.Location
+}
+
+func (c *timeOfDayCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteString(tm.In(c.loc).Format(layoutTimeOfDay))
+}
+
+func (c *timeOfDayCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		clock, err := time.Parse(layoutTimeOfDay, s)
+		if err != nil {
+			iter.ReportError("ReadTimeOfDay", err.Error())
+			return time.Time{}
+		}
+		date := c.ref().In(c.loc)
+		return time.Date(date.Year(), date.Month(), date.Day(),
+			clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), c.loc)
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadTimeOfDay", `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// Java `Instant.toString()` layouts, fractional seconds are printed in groups of 3 digits
+const (
+	layoutJavaInstant      = "2006-01-02T15:04:05Z"
+	layoutJavaInstantMilli = "2006-01-02T15:04:05.000Z"
+	layoutJavaInstantMicro = "2006-01-02T15:04:05.000000Z"
+	layoutJavaInstantNano  = "2006-01-02T15:04:05.000000000Z"
+)
+
+// JavaInstantCodec decodes/encodes timestamps in the format of Java's `Instant.toString()` (ie `2020-01-02T15:04:05.123Z`).
+// It decodes RFC3339 timestamps with any number of fractional digits as UTC.
+// It encodes timestamps in UTC using the minimal group of 0, 3, 6 or 9 fractional digits that keeps precision.
+func JavaInstantCodec() TimeCodec {
+	return &javaInstantCodec{}
+}
+
+type javaInstantCodec struct{}
+
+func (*javaInstantCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	tm = tm.UTC()
+	switch nsec := tm.Nanosecond(); {
+	case nsec == 0:
+		stream.WriteString(tm.Format(layoutJavaInstant))
+	case nsec%int(time.Millisecond) == 0:
+		stream.WriteString(tm.Format(layoutJavaInstantMilli))
+	case nsec%int(time.Microsecond) == 0:
+		stream.WriteString(tm.Format(layoutJavaInstantMicro))
+	default:
+		stream.WriteString(tm.Format(layoutJavaInstantNano))
+	}
+}
+
+func (*javaInstantCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			iter.ReportError("ReadJavaInstant", err.Error())
+			return time.Time{}
+		}
+		return tm.UTC()
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadJavaInstant", `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// Layouts for the time part of ISO 8601 week dates, the zone is optional (UTC)
+const (
+	layoutWeekTime       = "15:04:05.999999999Z07:00"
+	layoutWeekTimeNoZone = "15:04:05.999999999"
+)
+
+// ISOWeekCodec decodes/encodes timestamps using ISO 8601 week dates (ie `2020-W05-3T10:00:00Z`).
+// The date part is `YYYY-Www-D` where weeks start on Monday (day 1) and week 1 is the week with the year's
+// first Thursday, so week dates near the start or end of a year can fall in the adjacent calendar year.
+// The time part is optional (midnight UTC) and the zone of the time part is optional (UTC).
+// It encodes timestamps to week dates with a time part, keeping their offset and fractional seconds if any.
+func ISOWeekCodec() TimeCodec {
+	return &isoWeekCodec{}
+}
+
+type isoWeekCodec struct{}
+
+func (*isoWeekCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	year, week := tm.ISOWeek()
+	// ISO weekdays are 1 (Monday) to 7 (Sunday)
+	day := (int(tm.Weekday())+6)%7 + 1
+	stream.WriteString(fmt.Sprintf("%04d-W%02d-%dT%s", year, week, day, tm.Format(layoutWeekTime)))
+}
+
+func (*isoWeekCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}
+		}
+		tm, err := parseISOWeek(s)
+		if err != nil {
+			iter.ReportError("ReadISOWeek", err.Error())
+			return time.Time{}
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadISOWeek", `invalid JSON value`)
+		return time.Time{}
+	}
+}
+
+// parseISOWeek parses an ISO 8601 week date `YYYY-Www-D` with an optional `T` time part.
+func parseISOWeek(s string) (time.Time, error) {
+	const dateLength = len("2006-W01-1")
+	if len(s) < dateLength || s[4] != '-' || s[5] != 'W' || s[8] != '-' {
+		return time.Time{}, fmt.Errorf("invalid ISO week date %q", s)
+	}
+	year, ok := parseDigits(s[:4])
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid ISO week date year %q", s[:4])
+	}
+	week, ok := parseDigits(s[6:8])
+	if !ok || week < 1 || week > isoWeeksInYear(year) {
+		return time.Time{}, fmt.Errorf("invalid ISO week %q for year %d", s[6:8], year)
+	}
+	day, ok := parseDigits(s[9:dateLength])
+	if !ok || day < 1 || day > 7 {
+		return time.Time{}, fmt.Errorf("invalid ISO week day %q", s[9:dateLength])
+	}
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	date := monday.AddDate(0, 0, 7*(week-1)+day-1)
+
+	rest := s[dateLength:]
+	if rest == "" {
+		return date, nil
+	}
+	if rest[0] != 'T' {
+		return time.Time{}, fmt.Errorf("invalid ISO week date %q", s)
+	}
+	clock, err := time.Parse(layoutWeekTime, rest[1:])
+	if err != nil {
+		var e error
+		if clock, e = time.Parse(layoutWeekTimeNoZone, rest[1:]); e != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(),
+		clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), clock.Location()), nil
+}
+
+// isoWeeksInYear returns the number of ISO weeks (52 or 53) in a year.
+func isoWeeksInYear(year int) int {
+	// December 28th is always in the last week of its year
+	_, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return weeks
+}
+
+// parseDigits parses a non-negative decimal integer made only of digits.
+func parseDigits(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return 0, false
+		}
+		n = 10*n + int(s[i]-'0')
+	}
+	return n, true
+}
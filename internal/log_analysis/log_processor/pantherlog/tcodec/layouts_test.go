@@ -0,0 +1,347 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestISO8601CommaFractionCodec(t *testing.T) {
+	codec := ISO8601CommaFractionCodec()
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 123000000, time.UTC)
+	for _, input := range []string{
+		`"2020-01-02T15:04:05,123Z"`,
+		`"2020-01-02T15:04:05.123Z"`,
+		`"2020-01-02T17:04:05,123+02:00"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, expect.Equal(actual), input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05Z"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect.Truncate(time.Second), actual)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05,Z"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(expect, stream)
+		require.Equal(t, `"2020-01-02T15:04:05.123Z"`, string(stream.Buffer()))
+	}
+}
+
+func TestAssumeUTCIfNoZoneCodec(t *testing.T) {
+	codec := AssumeUTCIfNoZoneCodec(`2006-01-02T15:04:05`)
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect, actual)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05.123"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect.Add(123*time.Millisecond), actual)
+	}
+	for _, input := range []string{
+		`"2020-01-02T20:04:05+05:00"`,
+		`"2020-01-02T20:04:05+0500"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, expect.Equal(actual), input)
+		_, offset := actual.Zone()
+		require.Equal(t, 5*60*60, offset, input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05Z"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, expect.Equal(actual))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02 15:04:05"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Date(2020, 1, 2, 20, 4, 5, 0, time.FixedZone("", 5*60*60)), stream)
+		require.Equal(t, `"2020-01-02T15:04:05Z"`, string(stream.Buffer()))
+	}
+}
+
+func TestISO8601BasicCodec(t *testing.T) {
+	codec := ISO8601BasicCodec()
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	for input, output := range map[string]string{
+		`"20200102T150405Z"`:         `"20200102T150405Z"`,
+		`"20200102T200405+0500"`:     `"20200102T200405+0500"`,
+		`"20200102T150405.123Z"`:     `"20200102T150405.123Z"`,
+		`"20200102T100405.123-0500"`: `"20200102T100405.123-0500"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, expect.Equal(actual.Truncate(time.Second)), input)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, output, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05Z"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
+
+func TestMySQLZeroDateTolerantCodec(t *testing.T) {
+	codec := MySQLZeroDateTolerantCodec(`2006-01-02 15:04:05`)
+	for _, input := range []string{
+		`"0000-00-00 00:00:00"`,
+		`"0000-00-00 00:00:00.000000"`,
+		`"0000-00-00"`,
+		`""`,
+		`null`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, actual.IsZero(), input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02 15:04:05"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC), actual)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"0000-00-00 00:00:01"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `"0000-00-00 00:00:00"`, string(stream.Buffer()))
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC), stream)
+		require.Equal(t, `"2020-01-02 15:04:05"`, string(stream.Buffer()))
+	}
+	{
+		dateCodec := MySQLZeroDateTolerantCodec(`2006-01-02`)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		dateCodec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `"0000-00-00"`, string(stream.Buffer()))
+	}
+}
+
+func TestLayoutsCodec(t *testing.T) {
+	codec := LayoutsCodec("2006-01-02 15:04:05", time.RFC3339, "Jan 2 2006 15:04:05")
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, input := range []string{
+		`"2020-01-02 15:04:05"`,
+		`"2020-01-02T15:04:05Z"`,
+		`"Jan 2 2020 15:04:05"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, expect.Equal(actual), input)
+
+		// Encodes using the primary layout
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `"2020-01-02 15:04:05"`, string(stream.Buffer()), input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"02/01/2020"`)
+		actual := codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+		require.True(t, actual.IsZero())
+		msg := iter.Error.Error()
+		require.Contains(t, msg, `"2006-01-02 15:04:05"`)
+		require.Contains(t, msg, `"2006-01-02T15:04:05Z07:00"`)
+		require.Contains(t, msg, `"Jan 2 2006 15:04:05"`)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `null`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+	require.Equal(t, LayoutCodec(time.RFC3339), LayoutsCodec(time.RFC3339))
+}
+
+func TestTimeOfDayCodec(t *testing.T) {
+	est, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// Already the next day in UTC
+	ref := func() time.Time {
+		return time.Date(2020, 1, 3, 2, 0, 0, 0, time.UTC)
+	}
+	codec := TimeOfDayCodec(ref, est)
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"15:04:05"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, 0, est), actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `"15:04:05"`, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"15:04:05.123"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, int(123*time.Millisecond), est), actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual.UTC(), stream)
+		require.Equal(t, `"15:04:05.123"`, string(stream.Buffer()))
+	}
+	for _, input := range []string{`"25:00:00"`, `"15:04"`, `"2020-01-02T15:04:05Z"`, `905`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `null`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+	{
+		// Defaults to today in UTC
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"00:00:00"`)
+		actual := TimeOfDayCodec(nil, nil).DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.UTC, actual.Location())
+		require.Equal(t, time.Now().UTC().Format("2006-01-02"), actual.Format("2006-01-02"))
+	}
+}
+
+func TestJavaInstantCodec(t *testing.T) {
+	codec := JavaInstantCodec()
+	for input, expect := range map[string]time.Time{
+		`"2020-01-02T15:04:05Z"`:           time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+		`"2020-01-02T15:04:05.120Z"`:       time.Date(2020, 1, 2, 15, 4, 5, 120000000, time.UTC),
+		`"2020-01-02T15:04:05.123456Z"`:    time.Date(2020, 1, 2, 15, 4, 5, 123456000, time.UTC),
+		`"2020-01-02T15:04:05.123456789Z"`: time.Date(2020, 1, 2, 15, 4, 5, 123456789, time.UTC),
+		`"2020-01-02T15:04:05.000001Z"`:    time.Date(2020, 1, 2, 15, 4, 5, 1000, time.UTC),
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual, input)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, input, string(stream.Buffer()))
+	}
+	{
+		// Non-grouped fractions and zone offsets are decoded and encoded in Java's form
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T17:04:05.1+02:00"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `"2020-01-02T15:04:05.100Z"`, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02 15:04:05Z"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
+
+func TestISOWeekCodec(t *testing.T) {
+	codec := ISOWeekCodec()
+	for _, tc := range []struct {
+		input  string
+		expect time.Time
+		output string
+	}{
+		{`"2020-W05-3T10:00:00Z"`, time.Date(2020, 1, 29, 10, 0, 0, 0, time.UTC), `"2020-W05-3T10:00:00Z"`},
+		{`"2020-W05-3T12:00:00.5+02:00"`, time.Date(2020, 1, 29, 10, 0, 0, 5e8, time.UTC), `"2020-W05-3T12:00:00.5+02:00"`},
+		{`"2020-W05-3T10:00:00"`, time.Date(2020, 1, 29, 10, 0, 0, 0, time.UTC), `"2020-W05-3T10:00:00Z"`},
+		{`"2020-W05-3"`, time.Date(2020, 1, 29, 0, 0, 0, 0, time.UTC), `"2020-W05-3T00:00:00Z"`},
+		// Week 53 spills into the next calendar year
+		{`"2020-W53-5"`, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), `"2020-W53-5T00:00:00Z"`},
+		{`"2020-W53-7"`, time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), `"2020-W53-7T00:00:00Z"`},
+		// Week 1 starts in the previous calendar year
+		{`"2020-W01-1"`, time.Date(2019, 12, 30, 0, 0, 0, 0, time.UTC), `"2020-W01-1T00:00:00Z"`},
+		{`"2021-W01-1"`, time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC), `"2021-W01-1T00:00:00Z"`},
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, tc.input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, tc.input)
+		require.True(t, tc.expect.Equal(actual), "%s %s", tc.input, actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, tc.output, string(stream.Buffer()), tc.input)
+	}
+	for _, input := range []string{
+		`"2021-W53-1"`, // 2021 has 52 weeks
+		`"2020-W00-1"`,
+		`"2020-W54-1"`,
+		`"2020-W5-3"`,
+		`"2020-W05-0"`,
+		`"2020-W05-8"`,
+		`"2020-W0a-3"`,
+		`"2020-05-03"`,
+		`"2020-W05-3 10:00:00Z"`,
+		`"2020-W05-3T25:00:00Z"`,
+		`1580292000`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+		require.True(t, actual.IsZero(), input)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
@@ -0,0 +1,104 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"io"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// TimeDecoderWithMeta can decode time.Time values from a jsoniter.Iterator and also reports
+// the name of the format each value was decoded from.
+type TimeDecoderWithMeta interface {
+	DecodeTimeWithMeta(iter *jsoniter.Iterator) (time.Time, string)
+}
+
+// DecodeWithMeta adapts a TimeDecoderWithMeta to a TimeDecoder.
+// The format name of each decoded value is passed to `sink`.
+// Null values and values that fail to decode are not passed to `sink`.
+func DecodeWithMeta(dec TimeDecoderWithMeta, sink func(format string)) TimeDecoder {
+	return &metaDecoder{
+		decode: dec,
+		sink:   sink,
+	}
+}
+
+type metaDecoder struct {
+	decode TimeDecoderWithMeta
+	sink   func(format string)
+}
+
+func (d *metaDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	tm, format := d.decode.DecodeTimeWithMeta(iter)
+	// Numbers at the end of input report io.EOF but were decoded fully
+	if err := iter.Error; err != nil && err != io.EOF {
+		return tm
+	}
+	if format != "" && d.sink != nil {
+		d.sink(format)
+	}
+	return tm
+}
+
+// Format names reported by UnixOrRFC3339Decoder
+const (
+	FormatUnix    = "unix"
+	FormatRFC3339 = "rfc3339"
+)
+
+// UnixOrRFC3339Decoder decodes JSON numbers as seconds since UNIX epoch and JSON strings as RFC3339 timestamps.
+// It reports FormatUnix or FormatRFC3339 as the format of each value.
+func UnixOrRFC3339Decoder() TimeDecoderWithMeta {
+	return &unixOrRFC3339Decoder{}
+}
+
+type unixOrRFC3339Decoder struct{}
+
+func (d *unixOrRFC3339Decoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	tm, _ := d.DecodeTimeWithMeta(iter)
+	return tm
+}
+
+func (*unixOrRFC3339Decoder) DecodeTimeWithMeta(iter *jsoniter.Iterator) (time.Time, string) {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		f := iter.ReadFloat64()
+		return UnixSeconds(f), FormatUnix
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return time.Time{}, ""
+		}
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			iter.ReportError("ReadUnixOrRFC3339", err.Error())
+			return time.Time{}, ""
+		}
+		return tm, FormatRFC3339
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}, ""
+	default:
+		iter.Skip()
+		iter.ReportError("ReadUnixOrRFC3339", `invalid JSON value`)
+		return time.Time{}, ""
+	}
+}
@@ -0,0 +1,57 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWithMeta(t *testing.T) {
+	var formats []string
+	dec := DecodeWithMeta(UnixOrRFC3339Decoder(), func(format string) {
+		formats = append(formats, format)
+	})
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577977445 `)
+	require.Equal(t, expect, dec.DecodeTime(iter).UTC())
+	require.NoError(t, iter.Error)
+	require.Equal(t, []string{FormatUnix}, formats)
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05Z"`)
+	require.Equal(t, expect, dec.DecodeTime(iter).UTC())
+	require.NoError(t, iter.Error)
+	require.Equal(t, []string{FormatUnix, FormatRFC3339}, formats)
+
+	formats = nil
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `null`)
+	require.True(t, dec.DecodeTime(iter).IsZero())
+	require.NoError(t, iter.Error)
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02"`)
+	require.True(t, dec.DecodeTime(iter).IsZero())
+	require.Error(t, iter.Error)
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `{}`)
+	require.True(t, dec.DecodeTime(iter).IsZero())
+	require.Error(t, iter.Error)
+	require.Nil(t, formats)
+}
@@ -0,0 +1,467 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	layoutDateField = "2006-01-02"
+	layoutTimeField = "15:04:05.999999999"
+)
+
+// DateTimeFieldsCodec decodes timestamps split in separate date and time fields of a JSON object
+// (ie `{"date":"2020-01-02","time":"15:04:05"}`) and encodes them back to the same form.
+// The timestamp is decoded in `loc` (UTC if nil). If the time field is missing the timestamp is at midnight,
+// if the date field is missing the timestamp is zero.
+func DateTimeFieldsCodec(dateKey, timeKey string, loc *time.Location) TimeCodec {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &dateTimeFieldsCodec{
+		dateKey: dateKey,
+		timeKey: timeKey,
+		loc:     loc,
+	}
+}
+
+type dateTimeFieldsCodec struct {
+	dateKey string
+	timeKey string
+	loc     *time.Location
+}
+
+func (c *dateTimeFieldsCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	tm = tm.In(c.loc)
+	stream.WriteObjectStart()
+	stream.WriteObjectField(c.dateKey)
+	stream.WriteString(tm.Format(layoutDateField))
+	stream.WriteMore()
+	stream.WriteObjectField(c.timeKey)
+	stream.WriteString(tm.Format(layoutTimeField))
+	stream.WriteObjectEnd()
+}
+
+func (c *dateTimeFieldsCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadDateTimeFields", `invalid JSON value`)
+		return time.Time{}
+	}
+	var date, clock string
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case c.dateKey:
+			date = iter.ReadString()
+		case c.timeKey:
+			clock = iter.ReadString()
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+	if iter.Error != nil || date == "" {
+		return time.Time{}
+	}
+	if clock == "" {
+		tm, err := time.ParseInLocation(layoutDateField, date, c.loc)
+		if err != nil {
+			iter.ReportError("ReadDateTimeFields", err.Error())
+		}
+		return tm
+	}
+	tm, err := time.ParseInLocation(layoutDateField+"T"+layoutTimeField, date+"T"+clock, c.loc)
+	if err != nil {
+		iter.ReportError("ReadDateTimeFields", err.Error())
+	}
+	return tm
+}
+
+// W3C Extended Log File Format date and time fields are always in UTC
+// See https://www.w3.org/TR/WD-logfile.html
+const (
+	layoutW3CDate      = "2006-01-02"
+	layoutW3CTime      = "15:04:05"
+	w3cDateDirective   = "#Date:"
+	layoutW3CDirective = layoutW3CDate + " " + layoutW3CTime
+)
+
+// W3CDateTimeCodec decodes timestamps from the `date` and `time` fields of W3C Extended Log Format (IIS) records
+// (ie `{"date":"2020-01-02","time":"15:04:05"}`) in UTC.
+// It also decodes the value of a `#Date:` directive (ie `"2020-01-02 15:04:05"` or `"#Date: 2020-01-02 15:04:05"`).
+// It encodes timestamps to the split `date` and `time` fields in UTC, dropping fractional seconds.
+func W3CDateTimeCodec() TimeCodec {
+	return &w3cDateTimeCodec{}
+}
+
+type w3cDateTimeCodec struct{}
+
+func (*w3cDateTimeCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	tm = tm.UTC()
+	stream.WriteObjectStart()
+	stream.WriteObjectField("date")
+	stream.WriteString(tm.Format(layoutW3CDate))
+	stream.WriteMore()
+	stream.WriteObjectField("time")
+	stream.WriteString(tm.Format(layoutW3CTime))
+	stream.WriteObjectEnd()
+}
+
+func (*w3cDateTimeCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+	case jsoniter.StringValue:
+		s := strings.TrimSpace(strings.TrimPrefix(iter.ReadString(), w3cDateDirective))
+		if s == "" {
+			return time.Time{}
+		}
+		tm, err := time.ParseInLocation(layoutW3CDirective, s, time.UTC)
+		if err != nil {
+			iter.ReportError("ReadW3CDateTime", err.Error())
+		}
+		return tm
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadW3CDateTime", `invalid JSON value`)
+		return time.Time{}
+	}
+	var date, clock string
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case "date":
+			date = iter.ReadString()
+		case "time":
+			clock = iter.ReadString()
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+	if iter.Error != nil || date == "" {
+		return time.Time{}
+	}
+	if clock == "" {
+		tm, err := time.ParseInLocation(layoutW3CDate, date, time.UTC)
+		if err != nil {
+			iter.ReportError("ReadW3CDateTime", err.Error())
+		}
+		return tm
+	}
+	tm, err := time.ParseInLocation(layoutW3CDirective, date+" "+clock, time.UTC)
+	if err != nil {
+		iter.ReportError("ReadW3CDateTime", err.Error())
+	}
+	return tm
+}
+
+// UnitFromSiblingCodec decodes epoch timestamps whose unit is declared in a separate field of a JSON object
+// (ie `{"ts":1577923200000,"ts_unit":"ms"}`). The value can be a JSON number or string.
+// Supported units are `s`, `ms`, `us` and `ns` (see WordUnitEpochCodec for all unit words).
+// If the unit field is missing the value is decoded as seconds, if the value field is missing the timestamp is zero.
+// It encodes to seconds with a `s` unit, using the coarsest of `ms`, `us` or `ns` instead if needed to keep precision.
+func UnitFromSiblingCodec(valueKey, unitKey string) TimeCodec {
+	return &unitFromSiblingCodec{
+		valueKey: valueKey,
+		unitKey:  unitKey,
+	}
+}
+
+type unitFromSiblingCodec struct {
+	valueKey string
+	unitKey  string
+}
+
+// Units used to encode timestamps, from coarsest to finest
+var siblingEncodeUnits = []struct {
+	name string
+	unit time.Duration
+}{
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+	{"us", time.Microsecond},
+	{"ns", time.Nanosecond},
+}
+
+func (c *unitFromSiblingCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	nsec := tm.UnixNano()
+	for _, u := range siblingEncodeUnits {
+		if nsec%int64(u.unit) != 0 {
+			continue
+		}
+		stream.WriteObjectStart()
+		stream.WriteObjectField(c.valueKey)
+		stream.WriteInt64(nsec / int64(u.unit))
+		stream.WriteMore()
+		stream.WriteObjectField(c.unitKey)
+		stream.WriteString(u.name)
+		stream.WriteObjectEnd()
+		return
+	}
+}
+
+func (c *unitFromSiblingCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadUnitFromSibling", `invalid JSON value`)
+		return time.Time{}
+	}
+	var value, unitWord string
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case c.valueKey:
+			switch iter.WhatIsNext() {
+			case jsoniter.NumberValue:
+				value = string(iter.ReadNumber())
+			case jsoniter.StringValue:
+				value = iter.ReadString()
+			case jsoniter.NilValue:
+				iter.ReadNil()
+			default:
+				iter.Skip()
+				iter.ReportError("ReadUnitFromSibling", `invalid JSON value for `+c.valueKey)
+			}
+		case c.unitKey:
+			unitWord = iter.ReadString()
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+	if iter.Error != nil || value == "" {
+		return time.Time{}
+	}
+	unit := time.Second
+	if unitWord != "" {
+		u, ok := epochUnitWords[strings.ToLower(unitWord)]
+		if !ok {
+			iter.ReportError("ReadUnitFromSibling", "unknown unit "+unitWord)
+			return time.Time{}
+		}
+		unit = u
+	}
+	tm, err := parseEpoch(value, unit)
+	if err != nil {
+		iter.ReportError("ReadUnitFromSibling", err.Error())
+		return time.Time{}
+	}
+	return tm
+}
+
+// EpochWithOffsetCodec decodes epoch timestamps in `unit` with a time zone offset in minutes declared in a separate
+// field of a JSON object (ie `{"epoch":1577923200,"tz_offset_minutes":-300}`).
+// The epoch is the UTC instant, the offset only sets the location so that the timestamp formats to local wall time.
+// Both fields can be a JSON number or string. If the offset field is missing the timestamp is in UTC,
+// if the epoch field is missing the timestamp is zero.
+// It encodes both fields, with the epoch as an integer in `unit` and the offset of the timestamp location.
+func EpochWithOffsetCodec(epochKey, offsetKey string, unit time.Duration) TimeCodec {
+	if unit <= 0 {
+		unit = time.Second
+	}
+	return &epochWithOffsetCodec{
+		epochKey:  epochKey,
+		offsetKey: offsetKey,
+		unit:      unit,
+	}
+}
+
+type epochWithOffsetCodec struct {
+	epochKey  string
+	offsetKey string
+	unit      time.Duration
+}
+
+// Time zone offsets are less than a day
+const maxOffsetMinutes = 24 * 60
+
+func (c *epochWithOffsetCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	_, offset := tm.Zone()
+	stream.WriteObjectStart()
+	stream.WriteObjectField(c.epochKey)
+	stream.WriteInt64(tm.UnixNano() / int64(c.unit))
+	stream.WriteMore()
+	stream.WriteObjectField(c.offsetKey)
+	stream.WriteInt(offset / 60)
+	stream.WriteObjectEnd()
+}
+
+func (c *epochWithOffsetCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadEpochWithOffset", `invalid JSON value`)
+		return time.Time{}
+	}
+	var epoch, offset string
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case c.epochKey:
+			epoch = readNumberString(iter, "ReadEpochWithOffset", key)
+		case c.offsetKey:
+			offset = readNumberString(iter, "ReadEpochWithOffset", key)
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+	if iter.Error != nil || epoch == "" {
+		return time.Time{}
+	}
+	tm, err := parseEpoch(epoch, c.unit)
+	if err != nil {
+		iter.ReportError("ReadEpochWithOffset", err.Error())
+		return time.Time{}
+	}
+	if offset == "" {
+		return tm.UTC()
+	}
+	minutes, err := strconv.Atoi(offset)
+	if err != nil {
+		iter.ReportError("ReadEpochWithOffset", err.Error())
+		return time.Time{}
+	}
+	if minutes <= -maxOffsetMinutes || minutes >= maxOffsetMinutes {
+		iter.ReportError("ReadEpochWithOffset", "time zone offset out of range")
+		return time.Time{}
+	}
+	return tm.In(time.FixedZone("", minutes*60))
+}
+
+// ProtoTimestampCodec decodes timestamps in the JSON object form of a protobuf `Timestamp`
+// (ie `{"seconds":1590000000,"nanos":123456789}`) and encodes them back to the same form.
+// Both fields can be a JSON number or string. If the `nanos` field is missing the timestamp has no fractional seconds,
+// if the `seconds` field is missing the timestamp is zero.
+func ProtoTimestampCodec() TimeCodec {
+	return &protoTimestampCodec{}
+}
+
+type protoTimestampCodec struct{}
+
+func (*protoTimestampCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteObjectStart()
+	stream.WriteObjectField("seconds")
+	stream.WriteInt64(tm.Unix())
+	stream.WriteMore()
+	stream.WriteObjectField("nanos")
+	stream.WriteInt(tm.Nanosecond())
+	stream.WriteObjectEnd()
+}
+
+func (*protoTimestampCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return time.Time{}
+	default:
+		iter.Skip()
+		iter.ReportError("ReadProtoTimestamp", `invalid JSON value`)
+		return time.Time{}
+	}
+	var seconds, nanos string
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case "seconds":
+			seconds = readNumberString(iter, "ReadProtoTimestamp", key)
+		case "nanos":
+			nanos = readNumberString(iter, "ReadProtoTimestamp", key)
+		default:
+			iter.Skip()
+		}
+		return iter.Error == nil
+	})
+	if iter.Error != nil || seconds == "" {
+		return time.Time{}
+	}
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		iter.ReportError("ReadProtoTimestamp", err.Error())
+		return time.Time{}
+	}
+	var nsec int64
+	if nanos != "" {
+		if nsec, err = strconv.ParseInt(nanos, 10, 32); err != nil {
+			iter.ReportError("ReadProtoTimestamp", err.Error())
+			return time.Time{}
+		}
+		if nsec < 0 || nsec >= int64(time.Second) {
+			iter.ReportError("ReadProtoTimestamp", "nanos out of range")
+			return time.Time{}
+		}
+	}
+	return time.Unix(sec, nsec).UTC()
+}
+
+// readNumberString reads a JSON number or string value as a string, reporting an error for any other JSON value.
+func readNumberString(iter *jsoniter.Iterator, op, key string) string {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		return string(iter.ReadNumber())
+	case jsoniter.StringValue:
+		return iter.ReadString()
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return ""
+	default:
+		iter.Skip()
+		iter.ReportError(op, `invalid JSON value for `+key)
+		return ""
+	}
+}
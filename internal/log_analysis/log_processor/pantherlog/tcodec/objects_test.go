@@ -0,0 +1,269 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDateTimeFieldsCodec(t *testing.T) {
+	est, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	codec := DateTimeFieldsCodec("date", "time", est)
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"date":"2020-01-02","host":"foo","time":"15:04:05"}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, 0, est), actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, `{"date":"2020-01-02","time":"15:04:05"}`, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"time":"15:04:05.123","date":"2020-01-02"}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, int(123*time.Millisecond), est), actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual.UTC(), stream)
+		require.Equal(t, `{"date":"2020-01-02","time":"15:04:05.123"}`, string(stream.Buffer()))
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"date":"2020-01-02"}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, est), actual)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"time":"15:04:05"}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02 15:04:05"`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"date":"2020-01-02","time":"25:00:00"}`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
+
+func TestW3CDateTimeCodec(t *testing.T) {
+	codec := W3CDateTimeCodec()
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	{
+		input := `{"date":"2020-01-02","time":"15:04:05"}`
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"date":"2020-01-02","s-ip":"10.0.0.1","time":"15:04:05"}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect, actual)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, input, string(stream.Buffer()))
+	}
+	for _, input := range []string{`"#Date: 2020-01-02 15:04:05"`, `"2020-01-02 15:04:05"`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual, input)
+	}
+	{
+		// Encodes in UTC
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(expect.In(time.FixedZone("", -5*60*60)).Add(123*time.Millisecond), stream)
+		require.Equal(t, `{"date":"2020-01-02","time":"15:04:05"}`, string(stream.Buffer()))
+	}
+	for _, input := range []string{
+		`{"date":"2020-01-02","time":"3:04PM"}`,
+		`"#Date: 2020-01-02T15:04:05Z"`,
+		`12`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
+
+func TestUnitFromSiblingCodec(t *testing.T) {
+	codec := UnitFromSiblingCodec("ts", "ts_unit")
+	expect := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, input := range []string{
+		`{"ts":1577923200,"ts_unit":"s"}`,
+		`{"ts":1577923200000,"ts_unit":"ms"}`,
+		`{"ts_unit":"us","ts":1577923200000000}`,
+		`{"ts":"1577923200000000000","ts_unit":"ns"}`,
+		`{"ts":1577923200,"other":"foo"}`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual.UTC(), input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"ts_unit":"ms"}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"ts":1577923200,"ts_unit":"days"}`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200`)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	for tm, expect := range map[time.Time]string{
+		expect:                             `{"ts":1577923200,"ts_unit":"s"}`,
+		expect.Add(123 * time.Millisecond): `{"ts":1577923200123,"ts_unit":"ms"}`,
+		expect.Add(123 * time.Microsecond): `{"ts":1577923200000123,"ts_unit":"us"}`,
+		expect.Add(1 * time.Nanosecond):    `{"ts":1577923200000000001,"ts_unit":"ns"}`,
+		{}:                                 `null`,
+	} {
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(tm, stream)
+		require.Equal(t, expect, string(stream.Buffer()))
+	}
+}
+
+func TestEpochWithOffsetCodec(t *testing.T) {
+	codec := EpochWithOffsetCodec("epoch", "tz_offset_minutes", time.Second)
+	instant := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		input  string
+		offset int
+		wall   string
+		output string
+	}{
+		{`{"epoch":1577923200,"tz_offset_minutes":-300}`, -300, "2020-01-01T19:00:00-05:00",
+			`{"epoch":1577923200,"tz_offset_minutes":-300}`},
+		{`{"tz_offset_minutes":"330","epoch":"1577923200"}`, 330, "2020-01-02T05:30:00+05:30",
+			`{"epoch":1577923200,"tz_offset_minutes":330}`},
+		{`{"epoch":1577923200}`, 0, "2020-01-02T00:00:00Z",
+			`{"epoch":1577923200,"tz_offset_minutes":0}`},
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, tc.input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, tc.input)
+		require.True(t, instant.Equal(actual), tc.input)
+		_, offset := actual.Zone()
+		require.Equal(t, tc.offset*60, offset, tc.input)
+		require.Equal(t, tc.wall, actual.Format(time.RFC3339), tc.input)
+
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, tc.output, string(stream.Buffer()), tc.input)
+	}
+	{
+		codec := EpochWithOffsetCodec("ts", "tz", time.Millisecond)
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"ts":1577923200123,"tz":60}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, "2020-01-02T01:00:00.123+01:00", actual.Format(time.RFC3339Nano))
+	}
+	for _, input := range []string{
+		`{"epoch":1577923200,"tz_offset_minutes":1440}`,
+		`{"epoch":1577923200,"tz_offset_minutes":"-05:00"}`,
+		`{"epoch":1577923200,"tz_offset_minutes":[]}`,
+		`{"epoch":"foo"}`,
+		`1577923200`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"tz_offset_minutes":60}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+}
+
+func TestProtoTimestampCodec(t *testing.T) {
+	codec := ProtoTimestampCodec()
+	for input, tc := range map[string]struct {
+		expect time.Time
+		output string
+	}{
+		`{"seconds":1590000000,"nanos":123456789}`: {
+			time.Date(2020, 5, 20, 18, 40, 0, 123456789, time.UTC),
+			`{"seconds":1590000000,"nanos":123456789}`,
+		},
+		`{"nanos":5,"seconds":"1590000000"}`: {
+			time.Date(2020, 5, 20, 18, 40, 0, 5, time.UTC),
+			`{"seconds":1590000000,"nanos":5}`,
+		},
+		`{"seconds":1590000000}`: {
+			time.Date(2020, 5, 20, 18, 40, 0, 0, time.UTC),
+			`{"seconds":1590000000,"nanos":0}`,
+		},
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, tc.expect, actual, input)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(actual, stream)
+		require.Equal(t, tc.output, string(stream.Buffer()), input)
+	}
+	for _, input := range []string{
+		`{"seconds":1590000000,"nanos":1000000000}`,
+		`{"seconds":1590000000,"nanos":-1}`,
+		`{"seconds":1.5}`,
+		`{"seconds":[]}`,
+		`{"seconds":1590000000`,
+		`"2020-05-20T18:40:00Z"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{"nanos":5}`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Time{}, stream)
+		require.Equal(t, `null`, string(stream.Buffer()))
+	}
+}
@@ -20,6 +20,9 @@ package tcodec
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -28,13 +31,24 @@ var (
 		codecs: map[string]TimeCodec{
 			"unix":    UnixSecondsCodec(),
 			"unix_ms": UnixMillisecondsCodec(),
+			"unix_us": UnixMicrosecondsCodec(),
+			"unix_ns": UnixNanosecondsCodec(),
 			"rfc3339": Join(LayoutCodec(time.RFC3339), LayoutCodec(time.RFC3339Nano)),
 		},
 	}
 )
 
+// Registry maps names to TimeCodecs.
+//
+// Apart from registered names, a registry resolves parametric names on demand:
+//   - `unix:s`, `unix:ms`, `unix:us`, `unix:ns` for numeric timestamps since UNIX epoch
+//   - `layout:<GO_TIME_LAYOUT>` for timestamps in a Go time layout (ie `layout:2006-01-02`)
+//   - `in:<TZ>` or `in:<TZ>:<NAME>` to force a time zone on a codec (StdCodec is used if no name is given)
 type Registry struct {
+	mu     sync.RWMutex
 	codecs map[string]TimeCodec
+	// cache holds resolved parametric codecs
+	cache map[string]TimeCodec
 }
 
 func NewRegistry() *Registry {
@@ -56,6 +70,8 @@ func (r *Registry) Register(name string, codec TimeCodec) error {
 	if name == "" {
 		return errors.New("anonymous time codec")
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if _, duplicate := r.codecs[name]; duplicate {
 		return errors.New("duplicate time codec " + name)
 	}
@@ -70,18 +86,99 @@ func (r *Registry) set(name string, codec TimeCodec) {
 	r.codecs[name] = codec
 }
 
+// Lookup returns the TimeCodec for a name or nil if the name cannot be resolved.
 func (r *Registry) Lookup(name string) TimeCodec {
-	return r.codecs[name]
+	codec, _ := r.Resolve(name)
+	return codec
+}
+
+// Resolve returns the TimeCodec for a registered or parametric name.
+func (r *Registry) Resolve(name string) (TimeCodec, error) {
+	r.mu.RLock()
+	codec, ok := r.codecs[name]
+	if !ok {
+		codec, ok = r.cache[name]
+	}
+	r.mu.RUnlock()
+	if ok {
+		return codec, nil
+	}
+	codec, err := r.resolveSpec(name)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == nil {
+		r.cache = make(map[string]TimeCodec)
+	}
+	r.cache[name] = codec
+	return codec, nil
+}
+
+func (r *Registry) resolveSpec(spec string) (TimeCodec, error) {
+	pos := strings.IndexByte(spec, ':')
+	if pos == -1 {
+		return nil, fmt.Errorf("failed to resolve %q time codec", spec)
+	}
+	switch kind, arg := spec[:pos], spec[pos+1:]; kind {
+	case "unix":
+		switch arg {
+		case "s":
+			return UnixSecondsCodec(), nil
+		case "ms":
+			return UnixMillisecondsCodec(), nil
+		case "us":
+			return UnixMicrosecondsCodec(), nil
+		case "ns":
+			return UnixNanosecondsCodec(), nil
+		default:
+			return nil, fmt.Errorf("invalid time codec %q: unknown unit %q", spec, arg)
+		}
+	case "layout":
+		if arg == "" {
+			return nil, fmt.Errorf("invalid time codec %q: empty layout", spec)
+		}
+		return LayoutCodec(arg), nil
+	case "in":
+		zone, name := arg, ""
+		if pos := strings.IndexByte(arg, ':'); pos != -1 {
+			zone, name = arg[:pos], arg[pos+1:]
+		}
+		loc, err := time.LoadLocation(zone)
+		if err != nil || zone == "" {
+			return nil, fmt.Errorf("invalid time codec %q: unknown time zone %q", spec, zone)
+		}
+		if name == "" {
+			return In(loc, StdCodec()), nil
+		}
+		codec, err := r.Resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		return In(loc, codec), nil
+	default:
+		return nil, fmt.Errorf("invalid time codec %q", spec)
+	}
 }
 
 func (r *Registry) Extend(others ...*Registry) {
 	for _, other := range others {
-		if other == nil {
+		if other == nil || other == r {
 			continue
 		}
+		// Copy the codecs before locking r so that concurrent a.Extend(b) and b.Extend(a) do not deadlock
+		other.mu.RLock()
+		codecs := make(map[string]TimeCodec, len(other.codecs))
 		for name, codec := range other.codecs {
+			codecs[name] = codec
+		}
+		other.mu.RUnlock()
+		r.mu.Lock()
+		for name, codec := range codecs {
 			r.set(name, codec)
 		}
+		r.mu.Unlock()
 	}
 }
 
@@ -99,6 +196,23 @@ func Lookup(name string) TimeCodec {
 	return defaultRegistry.Lookup(name)
 }
 
+// RegisterCodec registers a TimeCodec by name in the default registry so that `tcodec:"<name>"` struct tags use it.
+// It panics if the name is empty or already registered, like MustRegister.
+func RegisterCodec(name string, codec TimeCodec) {
+	MustRegister(name, codec)
+}
+
+// LookupCodec finds a registered or parametric TimeCodec by name in the default registry.
+func LookupCodec(name string) (TimeCodec, bool) {
+	codec := Lookup(name)
+	return codec, codec != nil
+}
+
+// Resolve resolves a registered or parametric TimeCodec name using the default registry.
+func Resolve(name string) (TimeCodec, error) {
+	return defaultRegistry.Resolve(name)
+}
+
 func DefaultRegistry() *Registry {
 	return defaultRegistry
 }
@@ -19,8 +19,11 @@ package tcodec
  */
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,6 +51,28 @@ func TestRegistry_Extend(t *testing.T) {
 	require.NotNil(t, r.Lookup("foo"))
 	require.Equal(t, codec, r.Lookup("foo"))
 }
+
+func TestRegistry_ExtendConcurrent(t *testing.T) {
+	a, b := NewRegistry(), NewRegistry()
+	a.MustRegister("a", LayoutCodec(`2006`))
+	b.MustRegister("b", LayoutCodec(`2006-01`))
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.Extend(b)
+		}()
+		go func() {
+			defer wg.Done()
+			b.Extend(a)
+		}()
+	}
+	wg.Wait()
+	require.NotNil(t, a.Lookup("b"))
+	require.NotNil(t, b.Lookup("a"))
+}
+
 func TestRegistry_Register(t *testing.T) {
 	r := Registry{}
 	codec := LayoutCodec(`2006`)
@@ -64,3 +89,83 @@ func TestRegistry_Register(t *testing.T) {
 	require.Equal(t, codec, r.Lookup("foo"))
 	require.Nil(t, r.Lookup("bar"))
 }
+
+func TestRegistry_Resolve(t *testing.T) {
+	r := NewRegistry()
+	{
+		codec, err := r.Resolve("unix:ms")
+		require.NoError(t, err)
+		require.Equal(t, UnixMillisecondsCodec(), codec)
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577977445123"`)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, 123000000, time.UTC), tm.UTC())
+		// Parametric codecs are cached
+		cached, err := r.Resolve("unix:ms")
+		require.NoError(t, err)
+		require.Same(t, codec, cached)
+	}
+	{
+		codec, err := r.Resolve("layout:2006-01-02T15:04:05Z")
+		require.NoError(t, err)
+		require.Equal(t, LayoutCodec("2006-01-02T15:04:05Z"), codec)
+	}
+	{
+		codec, err := r.Resolve("unix:ns")
+		require.NoError(t, err)
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577977445123456789"`)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, 123456789, time.UTC), tm.UTC())
+	}
+	{
+		codec, err := r.Resolve("in:America/New_York:unix:us")
+		require.NoError(t, err)
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1577977445123456"`)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, "America/New_York", tm.Location().String())
+		require.Equal(t, time.Date(2020, 1, 2, 15, 4, 5, 123456000, time.UTC), tm.UTC())
+	}
+	{
+		codec, err := r.Resolve("in:UTC")
+		require.NoError(t, err)
+		require.NotNil(t, codec)
+	}
+	for _, spec := range []string{"unix:days", "layout:", "in:Mars/Olympus", "in:UTC:foo", "strftime:%Y", "foo"} {
+		codec, err := r.Resolve(spec)
+		require.Error(t, err, spec)
+		require.Nil(t, codec, spec)
+		require.Nil(t, r.Lookup(spec), spec)
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	const name = "test_register_codec"
+	codec := LayoutCodec("2006/01/02")
+	RegisterCodec(name, codec)
+	require.Panics(t, func() {
+		RegisterCodec(name, StdCodec())
+	})
+	require.Panics(t, func() {
+		RegisterCodec("", StdCodec())
+	})
+
+	actual, ok := LookupCodec(name)
+	require.True(t, ok)
+	require.Equal(t, codec, actual)
+	_, ok = LookupCodec("test_missing_codec")
+	require.False(t, ok)
+	_, ok = LookupCodec("unix:ms")
+	require.True(t, ok)
+
+	// Struct tags resolve through the default registry
+	type T struct {
+		Time time.Time `json:"tm" tcodec:"test_register_codec"`
+	}
+	api := jsoniter.Config{}.Froze()
+	api.RegisterExtension(&Extension{})
+	v := T{}
+	require.NoError(t, api.UnmarshalFromString(`{"tm":"2020/01/02"}`, &v))
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), v.Time)
+}
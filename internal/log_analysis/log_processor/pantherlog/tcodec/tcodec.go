@@ -19,6 +19,8 @@ package tcodec
  */
 
 import (
+	"errors"
+	"io"
 	"reflect"
 	"strconv"
 	"time"
@@ -111,40 +113,106 @@ func (codec *joinCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
 	return codec.decode.DecodeTime(iter)
 }
 
+// DecodeString decodes a timestamp string that is not part of a JSON document (ie a CSV column) using `dec`.
+// The string is passed to the decoder as a JSON string value.
+func DecodeString(dec TimeDecoder, s string) (time.Time, error) {
+	data, err := jsoniter.ConfigDefault.Marshal(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	iter := jsoniter.ConfigDefault.BorrowIterator(data)
+	defer jsoniter.ConfigDefault.ReturnIterator(iter)
+	tm := dec.DecodeTime(iter)
+	if err := iter.Error; err != nil && err != io.EOF {
+		return time.Time{}, err
+	}
+	return tm, nil
+}
+
+// EncodeString encodes a timestamp to a plain string using `enc`.
+// String values are unquoted and numbers are returned as is, `null` is returned as an empty string.
+func EncodeString(enc TimeEncoder, tm time.Time) (string, error) {
+	stream := jsoniter.ConfigDefault.BorrowStream(nil)
+	defer jsoniter.ConfigDefault.ReturnStream(stream)
+	enc.EncodeTime(tm, stream)
+	if stream.Error != nil {
+		return "", stream.Error
+	}
+	iter := jsoniter.ConfigDefault.BorrowIterator(stream.Buffer())
+	defer jsoniter.ConfigDefault.ReturnIterator(iter)
+	var s string
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s = iter.ReadString()
+	case jsoniter.NumberValue:
+		s = string(iter.ReadNumber())
+	case jsoniter.NilValue:
+		iter.ReadNil()
+	default:
+		return "", errors.New("encoded value is not a string or a number")
+	}
+	if err := iter.Error; err != nil && err != io.EOF {
+		return "", err
+	}
+	return s, nil
+}
+
 // UnixSeconds reads a timestamp from seconds since UNIX epoch.
 // Fractions of a second can be set using the fractional part of a float.
 // Precision is kept up to Microseconds to avoid float64 precision issues.
 func UnixSeconds(sec float64) time.Time {
 	// We lose nanosecond precision to microsecond to have stable results with float64 values.
-	const usec = float64(time.Second / time.Microsecond)
-	const precision = int64(time.Microsecond)
-	return time.Unix(0, int64(sec*usec)*precision)
+	return UnixSecondsPrecision(sec, time.Microsecond)
+}
+
+// UnixSecondsPrecision reads a timestamp from seconds since UNIX epoch keeping precision up to `unit`.
+// A float64 holds ~16 significant digits so for current timestamps anything finer than a microsecond
+// is a best-effort reconstruction and the last digits of the nanoseconds will not be stable.
+func UnixSecondsPrecision(sec float64, unit time.Duration) time.Time {
+	if unit <= 0 {
+		unit = time.Nanosecond
+	}
+	perSecond := float64(time.Second) / float64(unit)
+	return time.Unix(0, int64(sec*perSecond)*int64(unit))
 }
 
 // UnixSecondsCodec decodes/encodes a timestamp from seconds since UNIX epoch.
 // Fractions of a second can be set using the fractional part of a float.
 // Precision is kept up to Microseconds to avoid float64 precision issues.
 func UnixSecondsCodec() TimeCodec {
-	return &unixSecondsCodec{}
+	return UnixSecondsPrecisionCodec(time.Microsecond)
 }
 
-type unixSecondsCodec struct{}
+// UnixSecondsPrecisionCodec decodes/encodes a timestamp from seconds since UNIX epoch keeping precision up to `unit`.
+// See UnixSecondsPrecision for the caveats of using a precision finer than a microsecond.
+func UnixSecondsPrecisionCodec(unit time.Duration) TimeCodec {
+	if unit <= 0 {
+		unit = time.Nanosecond
+	}
+	return &unixSecondsCodec{
+		precision: unit,
+	}
+}
 
-func (*unixSecondsCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+type unixSecondsCodec struct {
+	precision time.Duration
+}
+
+func (c *unixSecondsCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
 	if tm.IsZero() {
 		stream.WriteNil()
 		return
 	}
-	tm = tm.Truncate(time.Microsecond)
+	tm = tm.Truncate(c.precision)
 	unixSeconds := time.Duration(tm.UnixNano()).Seconds()
 	stream.WriteFloat64(unixSeconds)
 }
 
-func (*unixSecondsCodec) DecodeTime(iter *jsoniter.Iterator) (tm time.Time) {
+func (c *unixSecondsCodec) DecodeTime(iter *jsoniter.Iterator) (tm time.Time) {
 	switch iter.WhatIsNext() {
 	case jsoniter.NumberValue:
 		f := iter.ReadFloat64()
-		return UnixSeconds(f)
+		return UnixSecondsPrecision(f, c.precision)
 	case jsoniter.NilValue:
 		iter.ReadNil()
 		return
@@ -158,7 +226,7 @@ func (*unixSecondsCodec) DecodeTime(iter *jsoniter.Iterator) (tm time.Time) {
 			iter.ReportError("ReadUnixSeconds", err.Error())
 			return
 		}
-		return UnixSeconds(f)
+		return UnixSecondsPrecision(f, c.precision)
 	default:
 		iter.Skip()
 		iter.ReportError("ReadUnixSeconds", `invalid JSON value`)
@@ -214,6 +282,106 @@ func (*unixMillisecondsCodec) DecodeTime(iter *jsoniter.Iterator) (tm time.Time)
 	}
 }
 
+// UnixMicroseconds reads a timestamp from microseconds since UNIX epoch.
+func UnixMicroseconds(n int64) time.Time {
+	return time.Unix(0, n*int64(time.Microsecond))
+}
+
+// UnixMicrosecondsCodec decodes/encodes a timestamps in UNIX microsecond epoch.
+// It decodes both string and number JSON values and encodes always to number.
+// Sub-microsecond precision is floored when encoding, so timestamps before 1970 encode to the preceding microsecond.
+func UnixMicrosecondsCodec() TimeCodec {
+	return &unixMicrosecondsCodec{}
+}
+
+type unixMicrosecondsCodec struct{}
+
+func (*unixMicrosecondsCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	nsec := tm.UnixNano()
+	usec := nsec / int64(time.Microsecond)
+	if nsec%int64(time.Microsecond) < 0 {
+		usec--
+	}
+	stream.WriteInt64(usec)
+}
+
+func (*unixMicrosecondsCodec) DecodeTime(iter *jsoniter.Iterator) (tm time.Time) {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		usec := iter.ReadInt64()
+		return UnixMicroseconds(usec)
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return
+		}
+		usec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			iter.ReportError("ReadUnixMicroseconds", err.Error())
+			return
+		}
+		return UnixMicroseconds(usec)
+	default:
+		iter.Skip()
+		iter.ReportError("ReadUnixMicroseconds", `invalid JSON value`)
+		return
+	}
+}
+
+// UnixNanoseconds reads a timestamp from nanoseconds since UNIX epoch.
+func UnixNanoseconds(n int64) time.Time {
+	return time.Unix(0, n)
+}
+
+// UnixNanosecondsCodec decodes/encodes a timestamps in UNIX nanosecond epoch (ie `time.Time.UnixNano()`).
+// It decodes both string and number JSON values and encodes always to number.
+func UnixNanosecondsCodec() TimeCodec {
+	return &unixNanosecondsCodec{}
+}
+
+type unixNanosecondsCodec struct{}
+
+func (*unixNanosecondsCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteNil()
+		return
+	}
+	stream.WriteInt64(tm.UnixNano())
+}
+
+func (*unixNanosecondsCodec) DecodeTime(iter *jsoniter.Iterator) (tm time.Time) {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		nsec := iter.ReadInt64()
+		return UnixNanoseconds(nsec)
+	case jsoniter.NilValue:
+		iter.ReadNil()
+		return
+	case jsoniter.StringValue:
+		s := iter.ReadString()
+		if s == "" {
+			return
+		}
+		nsec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			iter.ReportError("ReadUnixNanoseconds", err.Error())
+			return
+		}
+		return UnixNanoseconds(nsec)
+	default:
+		iter.Skip()
+		iter.ReportError("ReadUnixNanoseconds", `invalid JSON value`)
+		return
+	}
+}
+
 // LayoutCodec uses a time layout to decode/encode a timestamp from a JSON value.
 func LayoutCodec(layout string) TimeCodec {
 	return layoutCodec(layout)
@@ -273,6 +441,11 @@ type locEncoder struct {
 }
 
 func (e *locEncoder) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	// Avoid the conversion if the time is already in the target location
+	if tm.Location() == e.loc {
+		e.encode.EncodeTime(tm, stream)
+		return
+	}
 	e.encode.EncodeTime(tm.In(e.loc), stream)
 }
 
@@ -303,10 +476,24 @@ func NewTimeEncoder(enc TimeEncoder, typ reflect.Type) jsoniter.ValEncoder {
 	}
 	switch typ {
 	case typTime:
+		if omitZero(enc) {
+			return &jsonTimeOmitZeroEncoder{
+				jsonTimeEncoder{
+					encode: enc.EncodeTime,
+				},
+			}
+		}
 		return &jsonTimeEncoder{
 			encode: enc.EncodeTime,
 		}
 	case typTimePtr:
+		if omitZero(enc) {
+			return &jsonTimePtrOmitZeroEncoder{
+				jsonTimePtrEncoder{
+					encode: enc.EncodeTime,
+				},
+			}
+		}
 		return &jsonTimePtrEncoder{
 			encode: enc.EncodeTime,
 		}
@@ -337,21 +524,116 @@ func NewTimeDecoder(dec TimeDecoder, typ reflect.Type) jsoniter.ValDecoder {
 // StdCodec behaves like the default UnmarshalJSON/MarshalJSON for time.Time values.
 // The tcodec extension uses this TimeCodec when no `tcodec` tag is present on a field of type time.Time and
 // the extension has no Config.DefaultCodec defined.
+// Besides RFC3339 it also decodes the close variants in `stdDecodeLayouts` (ie `2006-01-02 15:04:05Z`).
 func StdCodec() TimeCodec {
 	return &stdCodec{}
 }
 
 type stdCodec struct{}
 
+// Layouts tried in order by StdCodec, fractional seconds of any length are accepted by all of them.
+var stdDecodeLayouts = []string{
+	time.RFC3339Nano,
+	// Space separator instead of `T`
+	"2006-01-02 15:04:05.999999999Z07:00",
+	// Offsets without a colon (ie `+0000`)
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z0700",
+}
+
 func (*stdCodec) DecodeTime(iter *jsoniter.Iterator) time.Time {
 	ts := iter.ReadString()
-	tm, err := time.Parse(time.RFC3339, ts)
-	if err != nil {
-		iter.ReportError(`DecodeTime`, err.Error())
+	if tm, ok := parseRFC3339Fast(ts); ok {
+		return tm
+	}
+	tm, err := time.Parse(stdDecodeLayouts[0], ts)
+	if err == nil {
+		return tm
+	}
+	for _, layout := range stdDecodeLayouts[1:] {
+		if tm, e := time.Parse(layout, ts); e == nil {
+			return tm
+		}
+	}
+	// Report the RFC3339 error, it is the expected format
+	iter.ReportError(`DecodeTime`, err.Error())
+	return time.Time{}
+}
+
+// parseRFC3339Fast parses the common `2006-01-02T15:04:05.999999999Z07:00` shape without scanning a layout.
+// It only accepts values it can parse exactly like `time.Parse(time.RFC3339Nano, s)` would, including the
+// location of the result, anything else (ie out of range fields or more than 9 fractional digits) is left
+// to time.Parse by returning false.
+func parseRFC3339Fast(s string) (time.Time, bool) {
+	const minLen = len("2006-01-02T15:04:05Z")
+	if len(s) < minLen || s[4] != '-' || s[7] != '-' || s[10] != 'T' || s[13] != ':' || s[16] != ':' {
+		return time.Time{}, false
+	}
+	year, ok1 := parseDigits(s[0:4])
+	month, ok2 := parseDigits(s[5:7])
+	day, ok3 := parseDigits(s[8:10])
+	hour, ok4 := parseDigits(s[11:13])
+	min, ok5 := parseDigits(s[14:16])
+	sec, ok6 := parseDigits(s[17:19])
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6) {
+		return time.Time{}, false
 	}
-	return tm
+	if month < 1 || month > 12 || day < 1 || day > daysIn(time.Month(month), year) ||
+		hour > 23 || min > 59 || sec > 59 {
+
+		return time.Time{}, false
+	}
+	s = s[19:]
+	nsec := 0
+	if s[0] == '.' {
+		n := 1
+		for n < len(s) && isDigit(s[n]) {
+			n++
+		}
+		digits := n - 1
+		if digits == 0 || digits > 9 {
+			return time.Time{}, false
+		}
+		nsec, _ = parseDigits(s[1:n])
+		for ; digits < 9; digits++ {
+			nsec *= 10
+		}
+		s = s[n:]
+	}
+	if s == "Z" {
+		return time.Date(year, time.Month(month), day, hour, min, sec, nsec, time.UTC), true
+	}
+	if len(s) != len("+07:00") || (s[0] != '+' && s[0] != '-') || s[3] != ':' {
+		return time.Time{}, false
+	}
+	offsetHours, ok1 := parseDigits(s[1:3])
+	offsetMinutes, ok2 := parseDigits(s[4:6])
+	if !(ok1 && ok2) || offsetHours > 23 || offsetMinutes > 59 {
+		return time.Time{}, false
+	}
+	offset := (offsetHours*60 + offsetMinutes) * 60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	tm := time.Date(year, time.Month(month), day, hour, min, sec, nsec, time.UTC)
+	tm = tm.Add(-time.Duration(offset) * time.Second)
+	// Like time.Parse, use the local time zone if the offset matches it
+	if _, localOffset := tm.In(time.Local).Zone(); localOffset == offset {
+		return tm.In(time.Local), true
+	}
+	return tm.In(time.FixedZone("", offset)), true
 }
 
+// daysIn returns the number of days of a month in a (proleptic Gregorian) year.
+func daysIn(month time.Month, year int) int {
+	if month == time.February && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		return 29
+	}
+	return daysInMonth[month-1]
+}
+
+var daysInMonth = [12]int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
 const layoutRFC3339NanoJSON = `"` + time.RFC3339Nano + `"`
 
 func (*stdCodec) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
@@ -374,6 +656,8 @@ type tryDecoder struct {
 func (d *tryDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
 	rawJSON := iter.SkipAndReturnBytes()
 	child := iter.Pool().BorrowIterator(rawJSON)
+	// Return the child iterator to the pool even if a decoder panics
+	defer iter.Pool().ReturnIterator(child)
 	for i, dec := range d.decoders {
 		if i != 0 {
 			child.ResetBytes(rawJSON)
@@ -381,12 +665,10 @@ func (d *tryDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
 		}
 		tm := dec.DecodeTime(child)
 		if child.Error == nil {
-			child.Pool().ReturnIterator(child)
 			return tm
 		}
 	}
 	iter.Error = child.Error
-	child.Pool().ReturnIterator(child)
 	return time.Time{}
 }
 
@@ -21,6 +21,8 @@ package tcodec
 import (
 	"fmt"
 	"io"
+	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,12 +35,58 @@ func TestUnixMilliseconds(t *testing.T) {
 	actual := UnixMilliseconds(1590364207259)
 	require.Equal(t, expect, actual.UTC())
 }
+func TestUnixMicroseconds(t *testing.T) {
+	expect := time.Date(2020, 05, 24, 23, 50, 07, 259123000, time.UTC)
+	actual := UnixMicroseconds(1590364207259123)
+	require.Equal(t, expect, actual.UTC())
+	require.Equal(t, time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC), UnixMicroseconds(-1).UTC())
+}
+func TestUnixNanoseconds(t *testing.T) {
+	expect := time.Date(2020, 05, 24, 23, 50, 07, 259123456, time.UTC)
+	actual := UnixNanoseconds(1590364207259123456)
+	require.Equal(t, expect, actual.UTC())
+}
 func TestUnixSeconds(t *testing.T) {
 	expect := time.Date(2020, 05, 24, 23, 50, 07, int(259*time.Millisecond.Nanoseconds()), time.UTC)
 	actual := UnixSeconds(1590364207.259)
 	require.Equal(t, expect, actual.UTC())
 }
 
+func TestUnixSecondsPrecision(t *testing.T) {
+	const sec = 1590364207.123456789
+	usec := UnixSecondsPrecision(sec, time.Microsecond)
+	require.Equal(t, UnixSeconds(sec), usec)
+	require.Equal(t, 123456*int(time.Microsecond), usec.Nanosecond())
+	nsec := UnixSecondsPrecision(sec, time.Nanosecond)
+	// float64 cannot hold all the digits, nanoseconds are a best-effort reconstruction
+	require.Equal(t, usec, nsec.Truncate(time.Microsecond))
+	require.NotEqual(t, usec, nsec)
+	require.InDelta(t, 123456789, nsec.Nanosecond(), float64(time.Microsecond))
+	msec := UnixSecondsPrecision(sec, time.Millisecond)
+	require.Equal(t, 123*int(time.Millisecond), msec.Nanosecond())
+}
+
+func TestUnixSecondsPrecisionCodec(t *testing.T) {
+	expect := time.Date(2020, 5, 24, 23, 50, 7, 123456789, time.UTC)
+	input := `1590364207.123456789`
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := UnixSecondsCodec().DecodeTime(iter)
+		require.Equal(t, expect.Truncate(time.Microsecond), actual.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := UnixSecondsPrecisionCodec(time.Nanosecond).DecodeTime(iter)
+		require.Equal(t, expect.Truncate(time.Microsecond), actual.UTC().Truncate(time.Microsecond))
+		require.InDelta(t, expect.Nanosecond(), actual.Nanosecond(), float64(time.Microsecond))
+	}
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		UnixSecondsPrecisionCodec(time.Millisecond).EncodeTime(expect, stream)
+		require.Equal(t, `1590364207.123`, string(stream.Buffer()))
+	}
+}
+
 func TestGlobalRegister(t *testing.T) {
 	require.NoError(t, Register("foo", LayoutCodec("2006")))
 	require.Error(t, Register("bar", nil))
@@ -133,6 +181,77 @@ func TestUnixMillisecondsDecoder(t *testing.T) {
 	require.Equal(t, expect.Format(time.RFC3339Nano), tm.UTC().Format(time.RFC3339Nano))
 }
 
+func TestUnixMicrosecondsCodec(t *testing.T) {
+	codec := UnixMicrosecondsCodec()
+	expect := time.Date(2020, 7, 20, 15, 12, 46, 369123000, time.UTC)
+	for _, input := range []string{`1595257966369123`, `"1595257966369123"`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.True(t, iter.Error == nil || iter.Error == io.EOF, input)
+		require.Equal(t, expect, tm.UTC(), input)
+	}
+	for _, input := range []string{`""`, `null`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, tm.IsZero(), input)
+	}
+	for _, input := range []string{`"foo"`, `"1595257966.369"`, `{}`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+
+	for _, tc := range []struct {
+		tm     time.Time
+		expect string
+	}{
+		{expect, `1595257966369123`},
+		{time.Time{}, `null`},
+		{time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC), `-1000000`},
+		{time.Date(1969, 12, 31, 23, 59, 59, 999998500, time.UTC), `-2`},
+	} {
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(tc.tm, stream)
+		require.Equal(t, tc.expect, string(stream.Buffer()))
+	}
+
+	// Negative values round-trip
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"-1500000"`)
+	tm := codec.DecodeTime(iter)
+	require.NoError(t, iter.Error)
+	require.Equal(t, time.Date(1969, 12, 31, 23, 59, 58, 500000000, time.UTC), tm.UTC())
+}
+
+func TestUnixNanosecondsCodec(t *testing.T) {
+	codec := UnixNanosecondsCodec()
+	expect := time.Date(2020, 7, 20, 15, 12, 46, 369123456, time.UTC)
+	for _, input := range []string{`1595257966369123456`, `"1595257966369123456"`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.True(t, iter.Error == nil || iter.Error == io.EOF, input)
+		require.Equal(t, expect, tm.UTC(), input)
+	}
+	for _, input := range []string{`""`, `null`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.True(t, tm.IsZero(), input)
+	}
+	for _, input := range []string{`"foo"`, `"1595257966.369"`, `{}`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(expect, stream)
+	require.Equal(t, `1595257966369123456`, string(stream.Buffer()))
+	stream = jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(time.Time{}, stream)
+	require.Equal(t, `null`, string(stream.Buffer()))
+}
+
 func TestUnixSecondsDecoder(t *testing.T) {
 	dec := UnixSecondsCodec()
 	iter := jsoniter.Parse(jsoniter.ConfigDefault, nil, 1024)
@@ -226,3 +345,191 @@ func TestTryDecoder_DecodeTime(t *testing.T) {
 		require.Error(t, iter.Error)
 	}
 }
+
+func TestTryDecoderPanic(t *testing.T) {
+	// jsoniter pools iterators in a sync.Pool, which cannot be inspected.
+	// ReturnIterator resets the iterator attachment so we use it to check that the child iterator was returned.
+	var child *jsoniter.Iterator
+	panicking := TimeDecoderFunc(func(iter *jsoniter.Iterator) time.Time {
+		child = iter
+		iter.Attachment = "borrowed"
+		panic("buggy decoder")
+	})
+	dec := TryDecoders(LayoutCodec(time.ANSIC), panicking)
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-07-20T15:12:46Z"`)
+	require.Panics(t, func() {
+		dec.DecodeTime(iter)
+	})
+	require.NotNil(t, child)
+	require.Nil(t, child.Attachment, "child iterator was not returned to the pool")
+}
+
+func TestEncodeIn(t *testing.T) {
+	est, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	tm := time.Date(2020, 1, 2, 15, 4, 5, 123, time.UTC)
+	for _, loc := range []*time.Location{time.UTC, est} {
+		enc := EncodeIn(loc, StdCodec())
+		for _, input := range []time.Time{tm, tm.In(est), tm.Local(), {}} {
+			expect := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+			StdCodec().EncodeTime(input.In(loc), expect)
+			actual := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+			enc.EncodeTime(input, actual)
+			require.Equal(t, string(expect.Buffer()), string(actual.Buffer()), "%s %s", loc, input)
+		}
+	}
+}
+
+func BenchmarkEncodeIn(b *testing.B) {
+	tm := time.Date(2020, 1, 2, 15, 4, 5, 123, time.UTC)
+	enc := EncodeIn(time.UTC, StdCodec())
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stream.SetBuffer(stream.Buffer()[:0])
+		enc.EncodeTime(tm, stream)
+	}
+}
+
+func TestStdCodec(t *testing.T) {
+	codec := StdCodec()
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for input, expect := range map[string]time.Time{
+		`"2020-01-02T03:04:05Z"`:                tm,
+		`"2020-01-02T03:04:05+00:00"`:           tm,
+		`"2020-01-02T05:04:05+02:00"`:           tm,
+		`"2020-01-02T03:04:05.1Z"`:              tm.Add(100 * time.Millisecond),
+		`"2020-01-02T03:04:05.123Z"`:            tm.Add(123 * time.Millisecond),
+		`"2020-01-02T03:04:05.123456Z"`:         tm.Add(123456 * time.Microsecond),
+		`"2020-01-02T03:04:05.123456789+00:00"`: tm.Add(123456789),
+		`"2020-01-02 03:04:05Z"`:                tm,
+		`"2020-01-02 03:04:05.123-01:00"`:       tm.Add(time.Hour + 123*time.Millisecond),
+		`"2020-01-02T03:04:05+0000"`:            tm,
+		`"2020-01-02 03:04:05.5+0000"`:          tm.Add(500 * time.Millisecond),
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual.UTC(), input)
+	}
+	for _, input := range []string{
+		`"2020-01-02 03:04:05"`,
+		`"2020-01-02T03:04"`,
+		`"02 Jan 20 03:04 UTC"`,
+		`""`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := codec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+		require.Contains(t, iter.Error.Error(), "parsing time", input)
+		require.True(t, actual.IsZero(), input)
+	}
+	// Encoding is unchanged
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	codec.EncodeTime(tm.Add(123*time.Millisecond), stream)
+	require.Equal(t, `"2020-01-02T03:04:05.123Z"`, string(stream.Buffer()))
+}
+
+func TestDecodeEncodeString(t *testing.T) {
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	layout := LayoutCodec("2006-01-02 15:04:05")
+	tm, err := DecodeString(layout, "2020-01-02 15:04:05")
+	require.NoError(t, err)
+	require.Equal(t, expect, tm)
+	s, err := EncodeString(layout, tm)
+	require.NoError(t, err)
+	require.Equal(t, "2020-01-02 15:04:05", s)
+
+	// Epoch codecs decode strings and encode to numbers
+	tm, err = DecodeString(UnixMillisecondsCodec(), "1577977445000")
+	require.NoError(t, err)
+	require.Equal(t, expect, tm.UTC())
+	s, err = EncodeString(UnixMillisecondsCodec(), tm)
+	require.NoError(t, err)
+	require.Equal(t, "1577977445000", s)
+
+	// Strings that need escaping in JSON
+	_, err = DecodeString(layout, `"2020-01-02\`)
+	require.Error(t, err)
+	_, err = DecodeString(layout, "foo")
+	require.Error(t, err)
+
+	tm, err = DecodeString(AutoCodec(), "2020-01-02T15:04:05Z")
+	require.NoError(t, err)
+	require.Equal(t, expect, tm.UTC())
+
+	s, err = EncodeString(UnixMillisecondsCodec(), time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, "", s)
+}
+
+// TestParseRFC3339FastRandom checks that parseRFC3339Fast agrees with time.Parse on random valid and corrupted inputs.
+func TestParseRFC3339FastRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	const alphabet = "0123456789-+:.TZtz "
+	var accepted int
+	for i := 0; i < 100000; i++ {
+		tm := time.Unix(rnd.Int63n(253402300800)-62135596800, rnd.Int63n(int64(time.Second)))
+		var offset int
+		if rnd.Intn(2) == 0 {
+			offset = (rnd.Intn(48) - 24) * 30 * 60
+		}
+		layout := "2006-01-02T15:04:05" + [...]string{"", ".0", ".000", ".000000", ".000000000", ".999999999"}[rnd.Intn(6)]
+		if offset == 0 && rnd.Intn(2) == 0 {
+			layout += "+00:00"
+		} else {
+			layout += "Z07:00"
+		}
+		input := tm.In(time.FixedZone("", offset)).Format(layout)
+		if rnd.Intn(2) == 0 {
+			// Corrupt the input
+			b := []byte(input)
+			b[rnd.Intn(len(b))] = alphabet[rnd.Intn(len(alphabet))]
+			input = string(b)
+			if rnd.Intn(4) == 0 {
+				input = input[:rnd.Intn(len(input))] + strings.Repeat("0", rnd.Intn(3))
+			}
+		}
+		expect, err := time.Parse(time.RFC3339Nano, input)
+		actual, ok := parseRFC3339Fast(input)
+		if !ok {
+			// Anything not accepted by the fast path is left to time.Parse
+			continue
+		}
+		require.NoError(t, err, input)
+		require.Equal(t, expect, actual, input)
+		accepted++
+	}
+	// Make sure the fast path was actually exercised
+	require.Greater(t, accepted, 50000)
+}
+
+func BenchmarkStdCodecDecode(b *testing.B) {
+	const input = "2020-01-02T15:04:05.123456Z"
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := parseRFC3339Fast(input); !ok {
+				b.Fatal("fast path failed")
+			}
+		}
+	})
+	b.Run("time.Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := time.Parse(time.RFC3339Nano, input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("StdCodec", func(b *testing.B) {
+		codec := StdCodec()
+		data := []byte(`"` + input + `"`)
+		iter := jsoniter.ConfigDefault.BorrowIterator(data)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			iter.ResetBytes(data)
+			codec.DecodeTime(iter)
+		}
+	})
+}
@@ -0,0 +1,617 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Policy defines how a TimeDecoder handles invalid values.
+type Policy int
+
+const (
+	// PolicyError reports an error for invalid values, failing the decoding of the whole record (default).
+	PolicyError Policy = iota
+	// PolicySkip silently ignores invalid values, decoding them as zero time.
+	PolicySkip
+	// PolicyReport passes invalid values to an ErrorReporter and decodes them as zero time.
+	PolicyReport
+)
+
+// ErrorReporter is called with the raw JSON of each invalid value decoded with PolicyReport.
+type ErrorReporter func(rawJSON []byte, err error)
+
+// WithPolicy wraps a TimeCodec so that invalid values are handled according to `policy`.
+// With PolicyReport, invalid values are passed to `report` (ie to log them with the caller's logger).
+// Encoding is delegated to `codec`.
+func WithPolicy(policy Policy, codec TimeCodec, report ErrorReporter) TimeCodec {
+	dec, enc := Split(codec)
+	if policy == PolicyError {
+		return Join(dec, enc)
+	}
+	if policy != PolicyReport {
+		report = nil
+	}
+	return &joinCodec{
+		decode: &policyDecoder{
+			decode: dec,
+			report: report,
+		},
+		encode: enc,
+	}
+}
+
+type policyDecoder struct {
+	decode TimeDecoder
+	report ErrorReporter
+}
+
+func (d *policyDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	rawJSON := iter.SkipAndReturnBytes()
+	if len(rawJSON) == 0 {
+		// Not a valid JSON value, we cannot recover
+		return time.Time{}
+	}
+	child := iter.Pool().BorrowIterator(rawJSON)
+	defer iter.Pool().ReturnIterator(child)
+
+	tm := d.decode.DecodeTime(child)
+	// Numbers at the end of input report io.EOF, the value was complete so we can ignore it.
+	if err := child.Error; err != nil && err != io.EOF {
+		if d.report != nil {
+			d.report(rawJSON, err)
+		}
+		return time.Time{}
+	}
+	return tm
+}
+
+// DecodeOrDefault wraps a TimeDecoder so that values `dec` fails to decode are replaced by the time returned
+// by `fallback` (ie the ingestion time) instead of failing the decoding of the whole record.
+// Values which decode to zero time without an error (ie `null`) are not replaced.
+// Invalid JSON cannot be recovered and is still reported as an error.
+func DecodeOrDefault(dec TimeDecoder, fallback func() time.Time) TimeDecoder {
+	return &defaultDecoder{
+		decode:   resolveDecoder(dec),
+		fallback: fallback,
+	}
+}
+
+type defaultDecoder struct {
+	decode   TimeDecoder
+	fallback func() time.Time
+}
+
+func (d *defaultDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	rawJSON := iter.SkipAndReturnBytes()
+	if len(rawJSON) == 0 {
+		// Not a valid JSON value, we cannot recover
+		return time.Time{}
+	}
+	child := iter.Pool().BorrowIterator(rawJSON)
+	defer iter.Pool().ReturnIterator(child)
+
+	tm := d.decode.DecodeTime(child)
+	// Numbers at the end of input report io.EOF, the value was complete so we can ignore it.
+	if err := child.Error; err != nil && err != io.EOF {
+		return d.fallback()
+	}
+	return tm
+}
+
+// ZeroAsZero wraps a TimeEncoder so that zero time values are encoded as `0` instead of `null`.
+// This is useful for numeric epoch encoders in array contexts where the value cannot be omitted and
+// consumers expect every element to be a number.
+// Non-zero values are delegated to `enc`.
+func ZeroAsZero(enc TimeEncoder) TimeEncoder {
+	return &zeroAsZeroEncoder{
+		encode: resolveEncoder(enc),
+	}
+}
+
+type zeroAsZeroEncoder struct {
+	encode TimeEncoder
+}
+
+func (e *zeroAsZeroEncoder) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if tm.IsZero() {
+		stream.WriteInt(0)
+		return
+	}
+	e.encode.EncodeTime(tm, stream)
+}
+
+// ZeroPolicy defines how zero time values are encoded.
+type ZeroPolicy int
+
+const (
+	// ZeroNull encodes zero time values as `null`.
+	ZeroNull ZeroPolicy = iota
+	// ZeroEmptyString encodes zero time values as an empty string `""`.
+	ZeroEmptyString
+	// ZeroOmit omits struct fields with zero time values even if they are not tagged with `omitempty`.
+	// Values that cannot be omitted (ie array elements) are encoded as `null`.
+	ZeroOmit
+	// ZeroFormat passes zero time values to the wrapped encoder unchanged.
+	// Most epoch codecs encode them as `null` while LayoutCodec formats them as any other value.
+	ZeroFormat
+)
+
+// WithZeroPolicy wraps a TimeCodec so that zero time values are encoded according to `policy`.
+// Non-zero values are delegated to `codec`.
+// Decoding is delegated to `codec`, with ZeroEmptyString `""` values also decode to zero time
+// so that the encoded output can always be decoded back.
+func WithZeroPolicy(policy ZeroPolicy, codec TimeCodec) TimeCodec {
+	dec, enc := Split(codec)
+	if unwrap, ok := enc.(*zeroPolicyEncoder); ok {
+		enc = unwrap.encode
+	}
+	if policy == ZeroEmptyString {
+		dec = &emptyStringDecoder{
+			decode: dec,
+		}
+	}
+	return &joinCodec{
+		decode: dec,
+		encode: &zeroPolicyEncoder{
+			encode: enc,
+			policy: policy,
+		},
+	}
+}
+
+type zeroPolicyEncoder struct {
+	encode TimeEncoder
+	policy ZeroPolicy
+}
+
+func (e *zeroPolicyEncoder) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if !tm.IsZero() {
+		e.encode.EncodeTime(tm, stream)
+		return
+	}
+	switch e.policy {
+	case ZeroEmptyString:
+		stream.WriteString("")
+	case ZeroFormat:
+		e.encode.EncodeTime(tm, stream)
+	default:
+		stream.WriteNil()
+	}
+}
+
+// omitZero checks if struct fields encoded with `enc` should be omitted when they hold a zero time value.
+func omitZero(enc TimeEncoder) bool {
+	e, ok := resolveEncoder(enc).(*zeroPolicyEncoder)
+	return ok && e.policy == ZeroOmit
+}
+
+type emptyStringDecoder struct {
+	decode TimeDecoder
+}
+
+func (d *emptyStringDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	if iter.WhatIsNext() != jsoniter.StringValue {
+		return d.decode.DecodeTime(iter)
+	}
+	rawJSON := iter.SkipAndReturnBytes()
+	if string(rawJSON) == `""` {
+		return time.Time{}
+	}
+	child := iter.Pool().BorrowIterator(rawJSON)
+	defer iter.Pool().ReturnIterator(child)
+	tm := d.decode.DecodeTime(child)
+	if err := child.Error; err != nil && err != io.EOF {
+		iter.ReportError("DecodeTime", err.Error())
+	}
+	return tm
+}
+
+// Unquote wraps a TimeDecoder so that string values wrapped in an extra layer of quotes
+// (ie `"\"2020-01-02T15:04:05Z\""` from double-encoded JSON) are unquoted before decoding.
+// Other values are passed to `dec` unchanged.
+func Unquote(dec TimeDecoder) TimeDecoder {
+	return &unquoteDecoder{
+		decode: resolveDecoder(dec),
+	}
+}
+
+type unquoteDecoder struct {
+	decode TimeDecoder
+}
+
+func (d *unquoteDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	if iter.WhatIsNext() != jsoniter.StringValue {
+		return d.decode.DecodeTime(iter)
+	}
+	rawJSON := iter.SkipAndReturnBytes()
+	child := iter.Pool().BorrowIterator(rawJSON)
+	defer iter.Pool().ReturnIterator(child)
+
+	s := child.ReadString()
+	child.ResetBytes(rawJSON)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := jsoniter.ConfigDefault.Marshal(s[1 : len(s)-1]); err == nil {
+			child.ResetBytes(unquoted)
+		}
+	}
+	child.Error = nil
+	tm := d.decode.DecodeTime(child)
+	if child.Error != nil {
+		iter.Error = child.Error
+	}
+	return tm
+}
+
+// DecimalComma wraps a TimeDecoder so that numeric string values with a comma as decimal separator
+// (ie `"1590000000,123"`) are decoded as if the separator was a period.
+// Strings with more than one comma or period (ie thousands separators) are reported as an error.
+// It is meant for numeric epoch decoders, other values are passed to `dec` unchanged.
+func DecimalComma(dec TimeDecoder) TimeDecoder {
+	return &decimalCommaDecoder{
+		decode: resolveDecoder(dec),
+	}
+}
+
+type decimalCommaDecoder struct {
+	decode TimeDecoder
+}
+
+func (d *decimalCommaDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	if iter.WhatIsNext() != jsoniter.StringValue {
+		return d.decode.DecodeTime(iter)
+	}
+	rawJSON := iter.SkipAndReturnBytes()
+	child := iter.Pool().BorrowIterator(rawJSON)
+	defer iter.Pool().ReturnIterator(child)
+
+	s := child.ReadString()
+	child.ResetBytes(rawJSON)
+	switch commas := strings.Count(s, ","); {
+	case commas+strings.Count(s, ".") > 1:
+		iter.ReportError("ReadDecimalComma", "multiple decimal separators in "+s)
+		return time.Time{}
+	case commas == 1:
+		if data, err := jsoniter.ConfigDefault.Marshal(strings.Replace(s, ",", ".", 1)); err == nil {
+			child.ResetBytes(data)
+		}
+	}
+	child.Error = nil
+	tm := d.decode.DecodeTime(child)
+	if child.Error != nil {
+		iter.Error = child.Error
+	}
+	return tm
+}
+
+// RoundMode defines how Bucket rounds timestamps to a multiple of a duration.
+type RoundMode int
+
+const (
+	// RoundFloor rounds down to the start of the bucket.
+	RoundFloor RoundMode = iota
+	// RoundCeil rounds up to the end of the bucket, timestamps on a boundary are unchanged.
+	RoundCeil
+	// RoundNearest rounds to the nearest boundary, halfway values round up.
+	RoundNearest
+)
+
+// Bucket wraps a TimeCodec so that decoded timestamps are rounded to a multiple of `d` since UNIX epoch
+// according to `mode`.
+// Unlike time.Truncate, buckets are aligned to UNIX epoch and rounding is consistent for pre-1970 timestamps
+// (ie flooring `1969-12-31T23:30:00Z` to an hour gives `1969-12-31T23:00:00Z`).
+// Timestamps must be within the range of time.UnixNano (years 1678 to 2262).
+// Encoding is delegated to `codec`.
+func Bucket(d time.Duration, mode RoundMode, codec TimeCodec) TimeCodec {
+	dec, enc := Split(codec)
+	if d <= 0 {
+		return Join(dec, enc)
+	}
+	return &joinCodec{
+		decode: &bucketDecoder{
+			decode: dec,
+			size:   d,
+			mode:   mode,
+		},
+		encode: enc,
+	}
+}
+
+type bucketDecoder struct {
+	decode TimeDecoder
+	size   time.Duration
+	mode   RoundMode
+}
+
+func (d *bucketDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	tm := d.decode.DecodeTime(iter)
+	if tm.IsZero() {
+		return tm
+	}
+	return roundEpoch(tm, d.size, d.mode)
+}
+
+// roundEpoch rounds `tm` to a multiple of `d` since UNIX epoch
+func roundEpoch(tm time.Time, d time.Duration, mode RoundMode) time.Time {
+	nsec, size := tm.UnixNano(), int64(d)
+	rem := nsec % size
+	if rem < 0 {
+		// Go's remainder has the sign of the dividend, we want floor semantics
+		rem += size
+	}
+	floor := nsec - rem
+	switch {
+	case rem == 0:
+		return tm
+	case mode == RoundCeil, mode == RoundNearest && 2*rem >= size:
+		return time.Unix(0, floor+size).In(tm.Location())
+	default:
+		return time.Unix(0, floor).In(tm.Location())
+	}
+}
+
+// Truncate wraps a TimeCodec so that encoded timestamps are truncated to a multiple of `d` using time.Time.Truncate.
+// Decoding is delegated to `codec`, use DecodeTruncate to also truncate decoded timestamps.
+func Truncate(d time.Duration, codec TimeCodec) TimeCodec {
+	dec, enc := Split(codec)
+	return &joinCodec{
+		decode: dec,
+		encode: newRoundEncoder(d, false, enc),
+	}
+}
+
+// Round wraps a TimeCodec so that encoded timestamps are rounded to a multiple of `d` using time.Time.Round.
+// Decoding is delegated to `codec`, use DecodeRound to also round decoded timestamps.
+func Round(d time.Duration, codec TimeCodec) TimeCodec {
+	dec, enc := Split(codec)
+	return &joinCodec{
+		decode: dec,
+		encode: newRoundEncoder(d, true, enc),
+	}
+}
+
+// DecodeTruncate truncates all decoded timestamps to a multiple of `d` using time.Time.Truncate.
+func DecodeTruncate(d time.Duration, dec TimeDecoder) TimeDecoder {
+	return newRoundDecoder(d, false, dec)
+}
+
+// DecodeRound rounds all decoded timestamps to a multiple of `d` using time.Time.Round.
+func DecodeRound(d time.Duration, dec TimeDecoder) TimeDecoder {
+	return newRoundDecoder(d, true, dec)
+}
+
+func newRoundEncoder(d time.Duration, round bool, enc TimeEncoder) TimeEncoder {
+	enc = resolveEncoder(enc)
+	// Only the outermost truncation/rounding applies
+	if unwrap, ok := enc.(*roundEncoder); ok {
+		enc = unwrap.encode
+	}
+	if d <= 0 {
+		return enc
+	}
+	return &roundEncoder{
+		encode: enc,
+		d:      d,
+		round:  round,
+	}
+}
+
+type roundEncoder struct {
+	encode TimeEncoder
+	d      time.Duration
+	round  bool
+}
+
+func (e *roundEncoder) EncodeTime(tm time.Time, stream *jsoniter.Stream) {
+	if e.round {
+		e.encode.EncodeTime(tm.Round(e.d), stream)
+		return
+	}
+	e.encode.EncodeTime(tm.Truncate(e.d), stream)
+}
+
+func newRoundDecoder(d time.Duration, round bool, dec TimeDecoder) TimeDecoder {
+	dec = resolveDecoder(dec)
+	// Only the outermost truncation/rounding applies
+	if unwrap, ok := dec.(*roundDecoder); ok {
+		dec = unwrap.decode
+	}
+	if d <= 0 {
+		return dec
+	}
+	return &roundDecoder{
+		decode: dec,
+		d:      d,
+		round:  round,
+	}
+}
+
+type roundDecoder struct {
+	decode TimeDecoder
+	d      time.Duration
+	round  bool
+}
+
+func (d *roundDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	tm := d.decode.DecodeTime(iter)
+	if d.round {
+		return tm.Round(d.d)
+	}
+	return tm.Truncate(d.d)
+}
+
+// RequireAligned wraps a TimeDecoder so that an error is reported if a decoded timestamp is not a multiple of `d`
+// since UNIX epoch (ie not on an hour boundary for `time.Hour`). Zero time is not checked.
+// Timestamps must be within the range of time.UnixNano (years 1678 to 2262).
+func RequireAligned(d time.Duration, dec TimeDecoder) TimeDecoder {
+	dec = resolveDecoder(dec)
+	if d <= 0 {
+		return dec
+	}
+	return &alignedDecoder{
+		decode: dec,
+		size:   d,
+	}
+}
+
+type alignedDecoder struct {
+	decode TimeDecoder
+	size   time.Duration
+}
+
+func (d *alignedDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	tm := d.decode.DecodeTime(iter)
+	if tm.IsZero() || iter.Error != nil {
+		return tm
+	}
+	if tm.UnixNano()%int64(d.size) != 0 {
+		iter.ReportError("RequireAligned", fmt.Sprintf("timestamp %s is not aligned to %s",
+			tm.Format(time.RFC3339Nano), d.size))
+		return time.Time{}
+	}
+	return tm
+}
+
+// Bounded wraps a TimeDecoder so that an error is reported if a decoded timestamp is outside `[min, max]`
+// (ie a millisecond value decoded as seconds). Zero time is not checked.
+func Bounded(min, max time.Time, dec TimeDecoder) TimeDecoder {
+	return &boundedDecoder{
+		decode: resolveDecoder(dec),
+		min:    min,
+		max:    max,
+	}
+}
+
+// Bounds used by SaneBounds
+var (
+	saneMinTime = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	saneMaxTime = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// SaneBounds wraps a TimeDecoder so that an error is reported if a decoded timestamp is not between
+// 1970-01-01 and 2100-01-01 UTC (see Bounded).
+func SaneBounds(dec TimeDecoder) TimeDecoder {
+	return Bounded(saneMinTime, saneMaxTime, dec)
+}
+
+type boundedDecoder struct {
+	decode   TimeDecoder
+	min, max time.Time
+}
+
+func (d *boundedDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	tm := d.decode.DecodeTime(iter)
+	if tm.IsZero() || iter.Error != nil {
+		return tm
+	}
+	if tm.Before(d.min) || tm.After(d.max) {
+		iter.ReportError("Bounded", fmt.Sprintf("timestamp %s is not between %s and %s",
+			tm.Format(time.RFC3339Nano), d.min.Format(time.RFC3339Nano), d.max.Format(time.RFC3339Nano)))
+		return time.Time{}
+	}
+	return tm
+}
+
+// LeapSecondTolerantCodec decodes timestamps with a leap second (ie `2016-12-31T23:59:60Z`) that `time.Parse` rejects.
+// If `codec` fails to decode a string value with a `:60` seconds field, the value is normalized to `:59`
+// and decoded again, adding one second to the result so the leap second maps to the following second.
+// Encoding is delegated to `codec`.
+func LeapSecondTolerantCodec(codec TimeCodec) TimeCodec {
+	dec, enc := Split(codec)
+	return &joinCodec{
+		decode: &leapSecondDecoder{
+			decode: dec,
+		},
+		encode: enc,
+	}
+}
+
+type leapSecondDecoder struct {
+	decode TimeDecoder
+}
+
+func (d *leapSecondDecoder) DecodeTime(iter *jsoniter.Iterator) time.Time {
+	if iter.WhatIsNext() != jsoniter.StringValue {
+		return d.decode.DecodeTime(iter)
+	}
+	rawJSON := iter.SkipAndReturnBytes()
+	child := iter.Pool().BorrowIterator(rawJSON)
+	defer iter.Pool().ReturnIterator(child)
+
+	tm := d.decode.DecodeTime(child)
+	if child.Error == nil {
+		return tm
+	}
+	err := child.Error
+
+	child.ResetBytes(rawJSON)
+	child.Error = nil
+	normalized, ok := normalizeLeapSecond(child.ReadString())
+	if !ok {
+		iter.Error = err
+		return time.Time{}
+	}
+	child.ResetBytes(normalized)
+	child.Error = nil
+	tm = d.decode.DecodeTime(child)
+	if child.Error != nil {
+		iter.Error = err
+		return time.Time{}
+	}
+	return tm.Add(time.Second)
+}
+
+// normalizeLeapSecond replaces a `:60` seconds field with `:59` and returns the value as a JSON string.
+// Only a `:60` following `hh:mm` is a seconds field, a minute (`23:60:00`) or offset (`+05:60`) of 60 is left as is.
+func normalizeLeapSecond(s string) ([]byte, bool) {
+	const leapSecond = ":60"
+	for offset := 0; offset < len(s); {
+		pos := strings.Index(s[offset:], leapSecond)
+		if pos == -1 {
+			return nil, false
+		}
+		pos += offset
+		end := pos + len(leapSecond)
+		if isSecondsField(s, pos) && (end == len(s) || !isDigit(s[end])) {
+			data, err := jsoniter.ConfigDefault.Marshal(s[:pos] + ":59" + s[end:])
+			return data, err == nil
+		}
+		offset = end
+	}
+	return nil, false
+}
+
+// isSecondsField checks that the `:` at pos follows an `hh:mm` time of day
+func isSecondsField(s string, pos int) bool {
+	const hhmm = len("hh:mm")
+	if pos < hhmm {
+		return false
+	}
+	t := s[pos-hhmm : pos]
+	return isDigit(t[0]) && isDigit(t[1]) && t[2] == ':' && isDigit(t[3]) && isDigit(t[4])
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
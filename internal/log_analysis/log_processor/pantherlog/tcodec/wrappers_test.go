@@ -0,0 +1,457 @@
+package tcodec
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeapSecondTolerantCodec(t *testing.T) {
+	codec := LeapSecondTolerantCodec(LayoutCodec(time.RFC3339Nano))
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2016-12-31T23:59:60Z"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		expect := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		require.Equal(t, expect, actual.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2016-12-31T23:59:60.5Z"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		expect := time.Date(2017, 1, 1, 0, 0, 0, int(500*time.Millisecond), time.UTC)
+		require.Equal(t, expect, actual.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2016-12-31T23:59:59Z"`)
+		actual := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		expect := time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC)
+		require.Equal(t, expect, actual.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2016-12-31T23:59:61Z"`)
+		_ = codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	// Only the seconds field is normalized
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2016-12-31T23:60:00Z"`)
+		_ = codec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	for _, input := range []string{
+		"2016-12-31T23:60:00Z",
+		"2016-12-31T23:59:59+05:60",
+		"2016-12-31T60:00:00Z",
+	} {
+		_, ok := normalizeLeapSecond(input)
+		require.False(t, ok, input)
+	}
+	normalized, ok := normalizeLeapSecond("2016-12-31T23:59:60+05:30")
+	require.True(t, ok)
+	require.Equal(t, `"2016-12-31T23:59:59+05:30"`, string(normalized))
+	{
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		codec.EncodeTime(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), stream)
+		require.Equal(t, `"2017-01-01T00:00:00Z"`, string(stream.Buffer()))
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	codec := UnixMillisecondsCodec()
+	const input = `{"a":"not a timestamp","b":1577923200000}`
+	decode := func(codec TimeCodec) (a, b time.Time, err error) {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+			switch key {
+			case "a":
+				a = codec.DecodeTime(iter)
+			case "b":
+				b = codec.DecodeTime(iter)
+			}
+			return iter.Error == nil
+		})
+		return a, b, iter.Error
+	}
+	expect := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	{
+		_, _, err := decode(WithPolicy(PolicyError, codec, nil))
+		require.Error(t, err)
+	}
+	{
+		a, b, err := decode(WithPolicy(PolicySkip, codec, nil))
+		require.NoError(t, err)
+		require.True(t, a.IsZero())
+		require.Equal(t, expect, b.UTC())
+	}
+	{
+		var reported []string
+		report := func(rawJSON []byte, err error) {
+			require.Error(t, err)
+			reported = append(reported, string(rawJSON))
+		}
+		a, b, err := decode(WithPolicy(PolicyReport, codec, report))
+		require.NoError(t, err)
+		require.True(t, a.IsZero())
+		require.Equal(t, expect, b.UTC())
+		require.Equal(t, []string{`"not a timestamp"`}, reported)
+
+		// The reporter is only used with PolicyReport
+		reported = nil
+		_, _, err = decode(WithPolicy(PolicySkip, codec, report))
+		require.NoError(t, err)
+		require.Empty(t, reported)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200000 `)
+		actual := WithPolicy(PolicySkip, codec, nil).DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, expect, actual.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `{`)
+		WithPolicy(PolicySkip, codec, nil).DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+}
+
+func TestDecodeOrDefault(t *testing.T) {
+	ingested := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	dec := DecodeOrDefault(UnixMillisecondsCodec(), func() time.Time { return ingested })
+
+	type T struct {
+		Time  time.Time `json:"tm"`
+		Value string    `json:"value"`
+	}
+	api := jsoniter.Config{}.Froze()
+	var v T
+	iter := api.BorrowIterator([]byte(`{"tm":"foo","value":"bar"}`))
+	defer api.ReturnIterator(iter)
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, key string) bool {
+		switch key {
+		case "tm":
+			v.Time = dec.DecodeTime(iter)
+		default:
+			v.Value = iter.ReadString()
+		}
+		return true
+	})
+	require.NoError(t, iter.Error)
+	require.Equal(t, T{Time: ingested, Value: "bar"}, v)
+
+	for input, expect := range map[string]time.Time{
+		`1577923200000 `: ingested,
+		`1577923200123 `: ingested.Add(123 * time.Millisecond),
+		`"abc"`:          ingested,
+		`{}`:             ingested,
+		`null`:           {},
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := dec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, tm.UTC(), input)
+	}
+
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `{"foo`)
+	_ = dec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+}
+
+func TestZeroAsZero(t *testing.T) {
+	enc := ZeroAsZero(UnixMillisecondsCodec())
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+	stream.WriteArrayStart()
+	enc.EncodeTime(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), stream)
+	stream.WriteMore()
+	enc.EncodeTime(time.Time{}, stream)
+	stream.WriteMore()
+	enc.EncodeTime(time.Date(2020, 1, 2, 0, 0, 0, int(123*time.Millisecond), time.UTC), stream)
+	stream.WriteArrayEnd()
+	require.Equal(t, `[1577923200000,0,1577923200123]`, string(stream.Buffer()))
+}
+
+func TestWithZeroPolicy(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		policy ZeroPolicy
+		codec  TimeCodec
+		expect string
+	}{
+		{ZeroNull, LayoutCodec("2006-01-02"), `["2020-01-02",null]`},
+		{ZeroEmptyString, UnixSecondsCodec(), `[1577923200,""]`},
+		{ZeroEmptyString, StdCodec(), `["2020-01-02T00:00:00Z",""]`},
+		{ZeroOmit, UnixMillisecondsCodec(), `[1577923200000,null]`},
+		{ZeroFormat, LayoutCodec("2006-01-02"), `["2020-01-02","0001-01-01"]`},
+		{ZeroFormat, UnixSecondsCodec(), `[1577923200,null]`},
+		// The outermost policy wins
+		{ZeroNull, WithZeroPolicy(ZeroEmptyString, LayoutCodec("2006-01-02")), `["2020-01-02",null]`},
+	} {
+		codec := WithZeroPolicy(tc.policy, tc.codec)
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		stream.WriteArrayStart()
+		codec.EncodeTime(tm, stream)
+		stream.WriteMore()
+		codec.EncodeTime(time.Time{}, stream)
+		stream.WriteArrayEnd()
+		require.Equal(t, tc.expect, string(stream.Buffer()))
+	}
+
+	// Empty strings decode back to zero time
+	codec := WithZeroPolicy(ZeroEmptyString, StdCodec())
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `""`)
+	require.True(t, codec.DecodeTime(iter).IsZero())
+	require.NoError(t, iter.Error)
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T00:00:00Z"`)
+	require.Equal(t, tm, codec.DecodeTime(iter).UTC())
+	require.NoError(t, iter.Error)
+	iter = jsoniter.ParseString(jsoniter.ConfigDefault, `"foo"`)
+	_ = codec.DecodeTime(iter)
+	require.Error(t, iter.Error)
+
+	// Struct fields are omitted without an `omitempty` tag
+	type T struct {
+		Time    time.Time  `json:"t" tcodec:"omit"`
+		TimePtr *time.Time `json:"t_ptr" tcodec:"omit"`
+		Other   time.Time  `json:"other" tcodec:"unix"`
+	}
+	codecs := NewRegistry()
+	codecs.MustRegister("omit", WithZeroPolicy(ZeroOmit, UnixSecondsCodec()))
+	codecs.MustRegister("unix", UnixSecondsCodec())
+	api := jsoniter.Config{}.Froze()
+	api.RegisterExtension(&Extension{
+		Codecs: codecs,
+	})
+	output, err := api.MarshalToString(&T{})
+	require.NoError(t, err)
+	require.Equal(t, `{"other":null}`, output)
+	output, err = api.MarshalToString(&T{Time: tm, TimePtr: &tm})
+	require.NoError(t, err)
+	require.Equal(t, `{"t":1577923200,"t_ptr":1577923200,"other":null}`, output)
+}
+
+func TestUnquote(t *testing.T) {
+	dec := Unquote(LayoutCodec(time.RFC3339))
+	expect := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, input := range []string{
+		`"\"2020-01-02T15:04:05Z\""`,
+		`"2020-01-02T15:04:05Z"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		actual := dec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, actual, input)
+	}
+	{
+		// Only a single layer of quotes is removed
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"\"\\\"2020-01-02T15:04:05Z\\\"\""`)
+		dec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `null`)
+		actual := dec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.True(t, actual.IsZero())
+	}
+}
+
+func TestDecimalComma(t *testing.T) {
+	dec := DecimalComma(UnixSecondsCodec())
+	expect := time.Date(2020, 5, 20, 18, 40, 0, int(123*time.Millisecond), time.UTC)
+	for _, input := range []string{
+		`"1590000000,123"`,
+		`"1590000000.123"`,
+		`1590000000.123 `,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := dec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		require.Equal(t, expect, tm.UTC(), input)
+	}
+	iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1590000000"`)
+	require.Equal(t, expect.Truncate(time.Second), dec.DecodeTime(iter).UTC())
+	require.NoError(t, iter.Error)
+
+	for _, input := range []string{
+		`"1,590,000,000"`,
+		`"1.590.000.000,123"`,
+		`"1590000000,123,4"`,
+		`"1590000000,12.3"`,
+		`"foo,1"`,
+	} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		_ = dec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	decode := func(codec TimeCodec, input string) time.Time {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := codec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+		return tm.UTC()
+	}
+	floor := Bucket(time.Hour, RoundFloor, LayoutCodec(time.RFC3339))
+	ceil := Bucket(time.Hour, RoundCeil, LayoutCodec(time.RFC3339))
+	nearest := Bucket(time.Hour, RoundNearest, LayoutCodec(time.RFC3339))
+
+	// Just after an hour boundary
+	require.Equal(t, time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC), decode(floor, `"2020-01-02T15:00:01Z"`))
+	require.Equal(t, time.Date(2020, 1, 2, 16, 0, 0, 0, time.UTC), decode(ceil, `"2020-01-02T15:00:01Z"`))
+	require.Equal(t, time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC), decode(nearest, `"2020-01-02T15:00:01Z"`))
+	require.Equal(t, time.Date(2020, 1, 2, 16, 0, 0, 0, time.UTC), decode(nearest, `"2020-01-02T15:30:00Z"`))
+	// On a boundary
+	require.Equal(t, time.Date(2020, 1, 2, 15, 0, 0, 0, time.UTC), decode(ceil, `"2020-01-02T15:00:00Z"`))
+
+	// Before 1970
+	require.Equal(t, time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC), decode(floor, `"1969-12-31T23:30:01Z"`))
+	require.Equal(t, time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), decode(ceil, `"1969-12-31T23:30:01Z"`))
+	require.Equal(t, time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC), decode(nearest, `"1969-12-31T23:29:59Z"`))
+
+	// Buckets are aligned to UNIX epoch
+	week := Bucket(7*24*time.Hour, RoundFloor, LayoutCodec(time.RFC3339))
+	require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), decode(week, `"2020-01-05T12:00:00Z"`))
+
+	// Location is preserved
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:45:00+02:00"`)
+		tm := floor.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, `2020-01-02T15:00:00+02:00`, tm.Format(time.RFC3339))
+	}
+	require.True(t, decode(floor, `null`).IsZero())
+}
+
+func TestTruncateRound(t *testing.T) {
+	encode := func(enc TimeEncoder, tm time.Time) string {
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 64)
+		enc.EncodeTime(tm, stream)
+		return string(stream.Buffer())
+	}
+	tm := time.Date(2020, 1, 2, 15, 4, 5, 987654321, time.UTC)
+	require.Equal(t, `"2020-01-02T15:04:05Z"`, encode(Truncate(time.Second, StdCodec()), tm))
+	require.Equal(t, `"2020-01-02T15:04:06Z"`, encode(Round(time.Second, StdCodec()), tm))
+	require.Equal(t, `1577977445987`, encode(Truncate(time.Millisecond, UnixMillisecondsCodec()), tm))
+
+	// Nested wrappers do not apply twice, the outermost wins
+	require.Equal(t, `"2020-01-02T15:04:05.987Z"`,
+		encode(Truncate(time.Millisecond, Round(time.Second, StdCodec())), tm))
+	// Composes with In
+	est := time.FixedZone("EST", -5*3600)
+	require.Equal(t, `"2020-01-02T10:04:06-05:00"`, encode(Round(time.Second, In(est, StdCodec())), tm))
+	require.Equal(t, `"2020-01-02T10:04:05-05:00"`, encode(In(est, Truncate(time.Second, StdCodec())), tm))
+	require.Equal(t, `null`, encode(Round(time.Second, UnixMillisecondsCodec()), time.Time{}))
+
+	// Decoding is unaffected unless requested
+	{
+		codec := Round(time.Second, StdCodec())
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05.987654321Z"`)
+		require.Equal(t, tm, codec.DecodeTime(iter).UTC())
+		require.NoError(t, iter.Error)
+	}
+	{
+		codec := Join(DecodeTruncate(time.Millisecond, DecodeRound(time.Second, StdCodec())), StdCodec())
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05.987654321Z"`)
+		require.Equal(t, tm.Truncate(time.Millisecond), codec.DecodeTime(iter).UTC())
+		require.NoError(t, iter.Error)
+	}
+	{
+		dec := DecodeRound(time.Second, StdCodec())
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:04:05.987654321Z"`)
+		require.Equal(t, tm.Round(time.Second), dec.DecodeTime(iter).UTC())
+		require.NoError(t, iter.Error)
+	}
+}
+
+func TestRequireAligned(t *testing.T) {
+	dec := RequireAligned(time.Hour, LayoutCodec(time.RFC3339))
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:00:00+02:00"`)
+		tm := dec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+		require.Equal(t, time.Date(2020, 1, 2, 13, 0, 0, 0, time.UTC), tm.UTC())
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:00:01Z"`)
+		tm := dec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+		require.Contains(t, iter.Error.Error(), "not aligned to 1h0m0s")
+		require.True(t, tm.IsZero())
+	}
+	{
+		// Offsets that are not whole hours are misaligned in UTC
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"2020-01-02T15:00:00+05:30"`)
+		dec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `"1969-12-31T23:00:00Z"`)
+		dec.DecodeTime(iter)
+		require.NoError(t, iter.Error)
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `null`)
+		require.True(t, dec.DecodeTime(iter).IsZero())
+		require.NoError(t, iter.Error)
+	}
+}
+
+func TestBounded(t *testing.T) {
+	min := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	max := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	dec := Bounded(min, max, LayoutCodec(time.RFC3339))
+	for _, input := range []string{`"2020-01-01T00:00:00Z"`, `"2020-06-01T00:00:00Z"`, `"2021-01-01T00:00:00Z"`, `null`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		dec.DecodeTime(iter)
+		require.NoError(t, iter.Error, input)
+	}
+	for _, input := range []string{`"2019-12-31T23:59:59Z"`, `"2021-01-01T00:00:01Z"`} {
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, input)
+		tm := dec.DecodeTime(iter)
+		require.Error(t, iter.Error, input)
+		require.True(t, tm.IsZero())
+	}
+}
+
+func TestSaneBounds(t *testing.T) {
+	dec := SaneBounds(UnixSecondsCodec())
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200 `)
+		require.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), dec.DecodeTime(iter).UTC())
+		require.NoError(t, iter.Error)
+	}
+	{
+		// Milliseconds decoded as seconds
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `1577923200000 `)
+		require.True(t, dec.DecodeTime(iter).IsZero())
+		require.Error(t, iter.Error)
+		require.Contains(t, iter.Error.Error(), "is not between 1970-01-01T00:00:00Z and 2100-01-01T00:00:00Z")
+	}
+	{
+		iter := jsoniter.ParseString(jsoniter.ConfigDefault, `-1 `)
+		dec.DecodeTime(iter)
+		require.Error(t, iter.Error)
+	}
+}
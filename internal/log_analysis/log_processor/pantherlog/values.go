@@ -98,6 +98,14 @@ func (b *ValueBuffer) Inspect() map[FieldID][]string {
 	return m
 }
 
+// InspectScan scans `input` into a new buffer and returns a sorted snapshot of the values.
+// This is mainly useful for tests.
+func InspectScan(scan ValueScannerFunc, input string) map[FieldID][]string {
+	b := ValueBuffer{}
+	scan(&b, input)
+	return b.Inspect()
+}
+
 // WriteValues adds values to the buffer.
 func (b *ValueBuffer) WriteValues(id FieldID, values ...string) {
 	currentValues := b.index[id]
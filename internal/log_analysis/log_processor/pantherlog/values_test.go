@@ -107,3 +107,13 @@ func TestValueBuffer_WriteValuesTo(t *testing.T) {
 		require.Equal(t, expect, samples)
 	}
 }
+
+func TestInspectScan(t *testing.T) {
+	scan := func(w ValueWriter, input string) {
+		w.WriteValues(FieldIPAddress, input, "")
+	}
+	require.Equal(t, map[FieldID][]string{
+		FieldIPAddress: {"127.0.0.1"},
+	}, InspectScan(scan, "127.0.0.1"))
+	require.Nil(t, InspectScan(scan, ""))
+}
@@ -20,7 +20,11 @@ package awslogs
 
 import (
 	"regexp"
+	"strings"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 )
 
@@ -28,14 +32,259 @@ var (
 	awsAccountIDRegex = regexp.MustCompile(`^\d{12}$`)
 )
 
+// AWS indicator fields
+// We start at an offset so that ids do not collide with the indicator fields defined in pantherlog.
+const (
+	FieldAccountID pantherlog.FieldID = 100 + iota
+	FieldInstanceID
+	FieldARN
+	FieldTag
+	FieldRegion
+	FieldImageRef
+	FieldKMSGrantID
+	FieldCloudTrailFile
+	FieldLoadBalancerName
+	FieldTargetGroupName
+	FieldAvailabilityZone
+	FieldResolverEndpointID
+	FieldResourceName
+	FieldARNShort
+	FieldStackName
+	FieldFindingType
+	FieldFindingCategory
+	FieldPrincipalID
+	FieldAccessKeyID
+	FieldSessionName
+	FieldSeverity
+	FieldS3Bucket
+	FieldIAMName
+	FieldAMIID
+	FieldVolumeID
+	FieldSnapshotID
+	FieldNetworkInterfaceID
+	FieldSecurityGroupID
+	FieldKMSKeyID
+	FieldLogGroup
+	FieldPartition
+)
+
+func init() {
+	pantherlog.MustRegisterIndicator(FieldAccountID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSAccountIds",
+		NameJSON:    "p_any_aws_account_ids",
+		Description: "Panther added field with collection of aws account ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldInstanceID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSInstanceIds",
+		NameJSON:    "p_any_aws_instance_ids",
+		Description: "Panther added field with collection of aws instance ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldARN, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSARNs",
+		NameJSON:    "p_any_aws_arns",
+		Description: "Panther added field with collection of aws arns associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldTag, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSTags",
+		NameJSON:    "p_any_aws_tags",
+		Description: "Panther added field with collection of aws tags associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldRegion, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSRegions",
+		NameJSON:    "p_any_aws_regions",
+		Description: "Panther added field with collection of aws regions associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldImageRef, pantherlog.FieldMeta{
+		Name:        "PantherAnyContainerImages",
+		NameJSON:    "p_any_container_images",
+		Description: "Panther added field with collection of container image references associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldKMSGrantID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSKMSGrantIDs",
+		NameJSON:    "p_any_aws_kms_grant_ids",
+		Description: "Panther added field with collection of aws KMS grant ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldCloudTrailFile, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSCloudTrailFiles",
+		NameJSON:    "p_any_aws_cloudtrail_files",
+		Description: "Panther added field with collection of aws CloudTrail log and digest file names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldLoadBalancerName, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSLoadBalancerNames",
+		NameJSON:    "p_any_aws_load_balancer_names",
+		Description: "Panther added field with collection of aws load balancer names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldTargetGroupName, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSTargetGroupNames",
+		NameJSON:    "p_any_aws_target_group_names",
+		Description: "Panther added field with collection of aws load balancer target group names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldAvailabilityZone, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSAvailabilityZones",
+		NameJSON:    "p_any_aws_availability_zones",
+		Description: "Panther added field with collection of aws availability zone names and ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldResolverEndpointID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSResolverEndpointIDs",
+		NameJSON:    "p_any_aws_resolver_endpoint_ids",
+		Description: "Panther added field with collection of aws route53 resolver endpoint ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldResourceName, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSResourceNames",
+		NameJSON:    "p_any_aws_resource_names",
+		Description: "Panther added field with collection of aws resource names (from Name tags) associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldARNShort, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSShortARNs",
+		NameJSON:    "p_any_aws_short_arns",
+		Description: "Panther added field with collection of region independent aws arns (service:account:resource) associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldStackName, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSStackNames",
+		NameJSON:    "p_any_aws_stack_names",
+		Description: "Panther added field with collection of aws cloudformation stack names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldFindingType, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSFindingTypes",
+		NameJSON:    "p_any_aws_finding_types",
+		Description: "Panther added field with collection of aws GuardDuty finding types associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldFindingCategory, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSFindingCategories",
+		NameJSON:    "p_any_aws_finding_categories",
+		Description: "Panther added field with collection of aws GuardDuty finding type categories associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldPrincipalID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSPrincipalIDs",
+		NameJSON:    "p_any_aws_principal_ids",
+		Description: "Panther added field with collection of aws principal ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldAccessKeyID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSAccessKeyIDs",
+		NameJSON:    "p_any_aws_access_key_ids",
+		Description: "Panther added field with collection of aws access key ids and IAM unique ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldSessionName, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSSessionNames",
+		NameJSON:    "p_any_aws_session_names",
+		Description: "Panther added field with collection of aws role session and federated user names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldSeverity, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSSeverities",
+		NameJSON:    "p_any_aws_severities",
+		Description: "Panther added field with collection of normalized aws finding severities (LOW, MEDIUM, HIGH or CRITICAL) associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldS3Bucket, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSS3Buckets",
+		NameJSON:    "p_any_aws_s3_buckets",
+		Description: "Panther added field with collection of aws S3 bucket names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldIAMName, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSIAMNames",
+		NameJSON:    "p_any_aws_iam_names",
+		Description: "Panther added field with collection of aws IAM role and user names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldAMIID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSAMIIDs",
+		NameJSON:    "p_any_aws_ami_ids",
+		Description: "Panther added field with collection of aws EC2 AMI ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldVolumeID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSVolumeIDs",
+		NameJSON:    "p_any_aws_volume_ids",
+		Description: "Panther added field with collection of aws EBS volume ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldSnapshotID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSSnapshotIDs",
+		NameJSON:    "p_any_aws_snapshot_ids",
+		Description: "Panther added field with collection of aws EBS snapshot ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldNetworkInterfaceID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSNetworkInterfaceIDs",
+		NameJSON:    "p_any_aws_network_interface_ids",
+		Description: "Panther added field with collection of aws EC2 network interface ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldSecurityGroupID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSSecurityGroupIDs",
+		NameJSON:    "p_any_aws_security_group_ids",
+		Description: "Panther added field with collection of aws EC2 security group ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldKMSKeyID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSKMSKeyIDs",
+		NameJSON:    "p_any_aws_kms_key_ids",
+		Description: "Panther added field with collection of aws KMS key ids and aliases associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldLogGroup, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSLogGroups",
+		NameJSON:    "p_any_aws_log_groups",
+		Description: "Panther added field with collection of aws CloudWatch Logs log group names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldPartition, pantherlog.FieldMeta{
+		Name:        "PantherAnyAWSPartitions",
+		NameJSON:    "p_any_aws_partitions",
+		Description: "Panther added field with collection of aws partitions (ie aws, aws-cn, aws-us-gov) associated with the row",
+	})
+
+	// All the fields ScanARN can produce values for
+	arnFields := []pantherlog.FieldID{
+		FieldARN, FieldPartition, FieldAccountID, FieldRegion, FieldInstanceID, FieldAMIID, FieldVolumeID, FieldSnapshotID,
+		FieldNetworkInterfaceID, FieldSecurityGroupID, FieldLoadBalancerName, FieldTargetGroupName, FieldStackName, FieldS3Bucket,
+		FieldIAMName, FieldSessionName, FieldKMSKeyID, FieldLogGroup,
+	}
+	pantherlog.MustRegisterScanner("aws_arn", pantherlog.ValueScannerFunc(ScanARN), arnFields...)
+	pantherlog.MustRegisterScanner("aws_arn_short", pantherlog.ValueScannerFunc(ScanARNShort),
+		append([]pantherlog.FieldID{FieldARNShort}, arnFields...)...)
+	pantherlog.MustRegisterScanner("aws_cfn_stack", pantherlog.ValueScannerFunc(ScanStackName),
+		FieldStackName, FieldARN, FieldAccountID, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_account_id", pantherlog.ValueScannerFunc(ScanAccountID), FieldAccountID)
+	pantherlog.MustRegisterScanner("aws_account_id_strict", pantherlog.ValueScannerFunc(ScanAccountIDStrict), FieldAccountID)
+	pantherlog.MustRegisterScanner("aws_s3_bucket", pantherlog.ValueScannerFunc(ScanS3Bucket),
+		FieldS3Bucket, FieldARN, FieldAccountID, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_iam_name", pantherlog.ValueScannerFunc(ScanIAMName),
+		FieldIAMName, FieldSessionName, FieldARN, FieldAccountID)
+	pantherlog.MustRegisterScanner("aws_region", pantherlog.ValueScannerFunc(ScanRegion), FieldRegion)
+	pantherlog.MustRegisterScanner("aws_partition", pantherlog.ValueScannerFunc(ScanPartition), FieldPartition)
+	pantherlog.MustRegisterScanner("aws_instance_id", pantherlog.ValueScannerFunc(ScanInstanceID), FieldInstanceID)
+	pantherlog.MustRegisterScanner("aws_ec2_id", pantherlog.ValueScannerFunc(ScanEC2ResourceID),
+		FieldInstanceID, FieldAMIID, FieldVolumeID, FieldSnapshotID, FieldNetworkInterfaceID, FieldSecurityGroupID)
+	pantherlog.MustRegisterScanner("aws_sg_id", pantherlog.ValueScannerFunc(ScanSecurityGroupID), FieldSecurityGroupID)
+	pantherlog.MustRegisterScanner("aws_tag", pantherlog.ValueScannerFunc(ScanTag), FieldTag)
+	pantherlog.MustRegisterScanner("aws_tag_name", pantherlog.ValueScannerFunc(ScanTagResourceName),
+		FieldTag, FieldResourceName)
+	pantherlog.MustRegisterScanner("aws_tag_ids", pantherlog.ValueScannerFunc(ScanTagIdentifiers),
+		append([]pantherlog.FieldID{FieldTag}, arnFields...)...)
+	pantherlog.MustRegisterScanner("container_image", pantherlog.ValueScannerFunc(ScanImageRef),
+		FieldImageRef, FieldAccountID, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_elb", pantherlog.ValueScannerFunc(ScanLoadBalancerName),
+		FieldLoadBalancerName, FieldARN, FieldAccountID, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_target_group", pantherlog.ValueScannerFunc(ScanTargetGroupName),
+		FieldTargetGroupName, FieldARN, FieldAccountID, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_az", pantherlog.ValueScannerFunc(ScanAvailabilityZone), FieldAvailabilityZone, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_resolver_endpoint", pantherlog.ValueScannerFunc(ScanResolverEndpointID),
+		FieldResolverEndpointID)
+	pantherlog.MustRegisterScanner("dns_query_name", pantherlog.ValueScannerFunc(ScanDNSQueryName),
+		pantherlog.FieldDomainName, pantherlog.FieldIPAddress)
+	pantherlog.MustRegisterScanner("aws_kms_key", pantherlog.ValueScannerFunc(ScanKMSKey), arnFields...)
+	pantherlog.MustRegisterScanner("aws_cloudwatch_log_group", pantherlog.ValueScannerFunc(ScanLogGroup), arnFields...)
+	pantherlog.MustRegisterScanner("aws_kms_grant_id", pantherlog.ValueScannerFunc(ScanKMSGrantID), FieldKMSGrantID)
+	pantherlog.MustRegisterScanner("aws_cloudtrail_file", pantherlog.ValueScannerFunc(ScanCloudTrailFile),
+		FieldCloudTrailFile, FieldAccountID, FieldRegion)
+	pantherlog.MustRegisterScanner("aws_finding_type", pantherlog.ValueScannerFunc(ScanFindingType),
+		FieldFindingType, FieldFindingCategory)
+	pantherlog.MustRegisterScanner("aws_principal_id", pantherlog.ValueScannerFunc(ScanPrincipalID),
+		FieldPrincipalID, FieldAccessKeyID, FieldAccountID, FieldSessionName)
+	pantherlog.MustRegisterScanner("aws_severity", pantherlog.ValueScannerFunc(ScanSeverity), FieldSeverity)
+}
+
 // nolint(lll)
 type AWSPantherLog struct {
 	parsers.PantherLog
 
-	PantherAnyAWSAccountIds  *parsers.PantherAnyString `json:"p_any_aws_account_ids,omitempty" description:"Panther added field with collection of aws account ids associated with the row"`
-	PantherAnyAWSInstanceIds *parsers.PantherAnyString `json:"p_any_aws_instance_ids,omitempty" description:"Panther added field with collection of aws instance ids associated with the row"`
-	PantherAnyAWSARNs        *parsers.PantherAnyString `json:"p_any_aws_arns,omitempty" description:"Panther added field with collection of aws arns associated with the row"`
-	PantherAnyAWSTags        *parsers.PantherAnyString `json:"p_any_aws_tags,omitempty" description:"Panther added field with collection of aws tags associated with the row"`
+	PantherAnyAWSAccountIds    *parsers.PantherAnyString `json:"p_any_aws_account_ids,omitempty" description:"Panther added field with collection of aws account ids associated with the row"`
+	PantherAnyAWSInstanceIds   *parsers.PantherAnyString `json:"p_any_aws_instance_ids,omitempty" description:"Panther added field with collection of aws instance ids associated with the row"`
+	PantherAnyAWSARNs          *parsers.PantherAnyString `json:"p_any_aws_arns,omitempty" description:"Panther added field with collection of aws arns associated with the row"`
+	PantherAnyAWSTags          *parsers.PantherAnyString `json:"p_any_aws_tags,omitempty" description:"Panther added field with collection of aws tags associated with the row"`
+	PantherAnyAWSResourceNames *parsers.PantherAnyString `json:"p_any_aws_resource_names,omitempty" description:"Panther added field with collection of aws resource names (from Name tags) associated with the row"`
 }
 
 func (pl *AWSPantherLog) AppendAnyAWSAccountIdPtrs(values ...*string) { // nolint
@@ -46,15 +295,27 @@ func (pl *AWSPantherLog) AppendAnyAWSAccountIdPtrs(values ...*string) { // nolin
 	}
 }
 
+// AppendAnyAWSAccountIds appends 12-digit account ids, whitespace around the ids is trimmed.
 func (pl *AWSPantherLog) AppendAnyAWSAccountIds(values ...string) {
+	pl.appendAnyAWSAccountIds(false, values)
+}
+
+// AppendAnyAWSAccountIdsStrict is like AppendAnyAWSAccountIds,
+// but also skips the `000000000000` placeholder some tools emit for unresolved accounts.
+func (pl *AWSPantherLog) AppendAnyAWSAccountIdsStrict(values ...string) {
+	pl.appendAnyAWSAccountIds(true, values)
+}
+
+func (pl *AWSPantherLog) appendAnyAWSAccountIds(strict bool, values []string) {
 	for _, value := range values {
-		if !awsAccountIDRegex.MatchString(value) {
+		id, ok := normalizeAccountID(value, strict)
+		if !ok {
 			continue
 		}
 		if pl.PantherAnyAWSAccountIds == nil { // lazy create
 			pl.PantherAnyAWSAccountIds = parsers.NewPantherAnyString()
 		}
-		parsers.AppendAnyString(pl.PantherAnyAWSAccountIds, value)
+		parsers.AppendAnyString(pl.PantherAnyAWSAccountIds, id)
 	}
 }
 
@@ -67,10 +328,15 @@ func (pl *AWSPantherLog) AppendAnyAWSInstanceIdPtrs(values ...*string) { // noli
 }
 
 func (pl *AWSPantherLog) AppendAnyAWSInstanceIds(values ...string) {
-	if pl.PantherAnyAWSInstanceIds == nil { // lazy create
-		pl.PantherAnyAWSInstanceIds = parsers.NewPantherAnyString()
+	for _, value := range values {
+		if !isInstanceID(value) {
+			continue
+		}
+		if pl.PantherAnyAWSInstanceIds == nil { // lazy create
+			pl.PantherAnyAWSInstanceIds = parsers.NewPantherAnyString()
+		}
+		parsers.AppendAnyString(pl.PantherAnyAWSInstanceIds, value)
 	}
-	parsers.AppendAnyString(pl.PantherAnyAWSInstanceIds, values...)
 }
 
 func (pl *AWSPantherLog) AppendAnyAWSARNPtrs(values ...*string) {
@@ -96,10 +362,91 @@ func (pl *AWSPantherLog) AppendAnyAWSTagPtrs(values ...*string) {
 	}
 }
 
-// NOTE: value should be of the form <key>:<value>
+// NOTE: value should be of the form <key>:<value>, values without a key are ignored.
+// Whitespace around the key is trimmed.
 func (pl *AWSPantherLog) AppendAnyAWSTags(values ...string) {
-	if pl.PantherAnyAWSTags == nil { // lazy create
-		pl.PantherAnyAWSTags = parsers.NewPantherAnyString()
+	for _, value := range values {
+		tag, ok := normalizeTag(value)
+		if !ok {
+			continue
+		}
+		if pl.PantherAnyAWSTags == nil { // lazy create
+			pl.PantherAnyAWSTags = parsers.NewPantherAnyString()
+		}
+		parsers.AppendAnyString(pl.PantherAnyAWSTags, tag)
+	}
+}
+
+// AppendAnyAWSTagsWithResourceName is like AppendAnyAWSTags, but also appends the value of `Name:<value>` tags
+// to the resource names. This is opt-in, AppendAnyAWSTags does not extract resource names.
+func (pl *AWSPantherLog) AppendAnyAWSTagsWithResourceName(values ...string) {
+	pl.AppendAnyAWSTags(values...)
+	for _, value := range values {
+		tag, ok := normalizeTag(value)
+		if !ok {
+			continue
+		}
+		if name, ok := nameTagValue(tag); ok {
+			pl.AppendAnyAWSResourceNames(name)
+		}
+	}
+}
+
+// AppendAnyAWSTagsWithIdentifiers is like AppendAnyAWSTags, but also appends tag values that are ARNs, instance ids
+// or account ids to their fields. This is opt-in, AppendAnyAWSTags does not look into tag values.
+func (pl *AWSPantherLog) AppendAnyAWSTagsWithIdentifiers(values ...string) {
+	pl.AppendAnyAWSTags(values...)
+	for _, tag := range values {
+		value, ok := tagValue(tag)
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(value, "arn:"):
+			parsedARN, err := arn.Parse(value)
+			if err != nil {
+				continue
+			}
+			pl.AppendAnyAWSARNs(value)
+			pl.AppendAnyAWSAccountIds(parsedARN.AccountID)
+		case strings.HasPrefix(value, "i-"):
+			pl.AppendAnyAWSInstanceIds(value)
+		default:
+			pl.AppendAnyAWSAccountIds(value)
+		}
+	}
+}
+
+func (pl *AWSPantherLog) AppendAnyAWSResourceNames(values ...string) {
+	if pl.PantherAnyAWSResourceNames == nil { // lazy create
+		pl.PantherAnyAWSResourceNames = parsers.NewPantherAnyString()
+	}
+	parsers.AppendAnyString(pl.PantherAnyAWSResourceNames, values...)
+}
+
+// ScanARNs scans each value with ScanARN, appending the ARNs and the values derived from them
+// (ie account ids and instance ids) in one call. Invalid ARNs are skipped silently, as ScanARN does.
+func (pl *AWSPantherLog) ScanARNs(values ...string) {
+	for _, value := range values {
+		ScanARN(pl, value)
+	}
+}
+
+var _ pantherlog.ValueWriter = (*AWSPantherLog)(nil)
+
+// WriteValues implements pantherlog.ValueWriter interface so that scanners can write to the log.
+// Values for fields that AWSPantherLog has no column for (ie regions) are dropped.
+func (pl *AWSPantherLog) WriteValues(field pantherlog.FieldID, values ...string) {
+	switch field {
+	case FieldAccountID:
+		pl.AppendAnyAWSAccountIds(values...)
+	case FieldInstanceID:
+		pl.AppendAnyAWSInstanceIds(values...)
+	case FieldARN:
+		pl.AppendAnyAWSARNs(values...)
+	case FieldTag:
+		pl.AppendAnyAWSTags(values...)
+	case FieldResourceName:
+		pl.AppendAnyAWSResourceNames(values...)
 	}
-	parsers.AppendAnyString(pl.PantherAnyAWSTags, values...)
 }
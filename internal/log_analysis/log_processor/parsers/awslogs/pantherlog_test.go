@@ -23,6 +23,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
 	"github.com/panther-labs/panther/internal/log_analysis/log_processor/parsers"
 )
 
@@ -50,19 +51,37 @@ func TestAppendAnyAWSAccountIds(t *testing.T) {
 	expectedAny = nil
 	event.AppendAnyAWSAccountIds(value)
 	require.Equal(t, expectedAny, event.PantherAnyAWSAccountIds)
+
+	// whitespace is trimmed
+	event = AWSPantherLog{}
+	expectedAny = parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedAny, "012345678912", "000000000000")
+	event.AppendAnyAWSAccountIds(" 012345678912\n", "000000000000")
+	require.Equal(t, expectedAny, event.PantherAnyAWSAccountIds)
+
+	// strict mode skips the placeholder account id
+	event = AWSPantherLog{}
+	expectedAny = parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedAny, "012345678912")
+	event.AppendAnyAWSAccountIdsStrict(" 012345678912", "000000000000")
+	require.Equal(t, expectedAny, event.PantherAnyAWSAccountIds)
 }
 
 func TestAppendAnyAWSInstanceIds(t *testing.T) {
 	event := AWSPantherLog{}
-	value := "a"
+	value := "i-abc"
 	expectedAny := parsers.NewPantherAnyString()
 	parsers.AppendAnyString(expectedAny, value)
-	event.AppendAnyAWSInstanceIds(value)
+	event.AppendAnyAWSInstanceIds("", "foo", value)
 	require.Equal(t, expectedAny, event.PantherAnyAWSInstanceIds)
 
 	event = AWSPantherLog{}
 	event.AppendAnyAWSInstanceIdPtrs(&value)
 	require.Equal(t, expectedAny, event.PantherAnyAWSInstanceIds)
+
+	event = AWSPantherLog{}
+	event.AppendAnyAWSInstanceIds("", "foo", "i-")
+	require.Nil(t, event.PantherAnyAWSInstanceIds)
 }
 
 func TestAppendAnyAWSARNs(t *testing.T) {
@@ -80,7 +99,7 @@ func TestAppendAnyAWSARNs(t *testing.T) {
 
 func TestAppendAnyAWSTags(t *testing.T) {
 	event := AWSPantherLog{}
-	value := "a"
+	value := "a:b"
 	expectedAny := parsers.NewPantherAnyString()
 	parsers.AppendAnyString(expectedAny, value)
 	event.AppendAnyAWSTags(value)
@@ -89,4 +108,109 @@ func TestAppendAnyAWSTags(t *testing.T) {
 	event = AWSPantherLog{}
 	event.AppendAnyAWSTagPtrs(&value)
 	require.Equal(t, expectedAny, event.PantherAnyAWSTags)
+
+	// malformed tags are ignored
+	event = AWSPantherLog{}
+	event.AppendAnyAWSTags("a", ":b", "")
+	require.Nil(t, event.PantherAnyAWSTags)
+
+	// empty values are allowed, keys are trimmed and tags are split on the first colon only
+	event = AWSPantherLog{}
+	event.AppendAnyAWSTags("a:", " env :prod", "owner:arn:aws:iam::123456789012:user/alice")
+	expectedAny = parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedAny, "a:", "env:prod", "owner:arn:aws:iam::123456789012:user/alice")
+	require.Equal(t, expectedAny, event.PantherAnyAWSTags)
+}
+
+func TestAppendAnyAWSTagsWithResourceName(t *testing.T) {
+	event := AWSPantherLog{}
+	event.AppendAnyAWSTags("Name:web-server")
+	require.Nil(t, event.PantherAnyAWSResourceNames)
+
+	event = AWSPantherLog{}
+	event.AppendAnyAWSTagsWithResourceName("Name:web-server", "env:prod")
+	expectedTags := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedTags, "Name:web-server", "env:prod")
+	require.Equal(t, expectedTags, event.PantherAnyAWSTags)
+	expectedNames := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedNames, "web-server")
+	require.Equal(t, expectedNames, event.PantherAnyAWSResourceNames)
+}
+
+func TestAppendAnyAWSTagsWithIdentifiers(t *testing.T) {
+	event := AWSPantherLog{}
+	event.AppendAnyAWSTags("parent:i-0abcdef1234567890")
+	require.Nil(t, event.PantherAnyAWSInstanceIds)
+
+	event = AWSPantherLog{}
+	event.AppendAnyAWSTagsWithIdentifiers("owner:arn:aws:iam::123456789012:user/alice", "parent:i-0abcdef1234567890",
+		"env:prod")
+	expectedTags := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedTags, "owner:arn:aws:iam::123456789012:user/alice", "parent:i-0abcdef1234567890",
+		"env:prod")
+	require.Equal(t, expectedTags, event.PantherAnyAWSTags)
+	expectedARNs := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedARNs, "arn:aws:iam::123456789012:user/alice")
+	require.Equal(t, expectedARNs, event.PantherAnyAWSARNs)
+	expectedAccounts := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedAccounts, "123456789012")
+	require.Equal(t, expectedAccounts, event.PantherAnyAWSAccountIds)
+	expectedInstances := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedInstances, "i-0abcdef1234567890")
+	require.Equal(t, expectedInstances, event.PantherAnyAWSInstanceIds)
+}
+
+func TestScanARNs(t *testing.T) {
+	event := AWSPantherLog{}
+	event.ScanARNs(
+		"arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890",
+		"arn:aws:iam::210987654321:user/alice",
+		"not-an-arn",
+		"arn:aws:s3",
+	)
+	expectedARNs := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedARNs,
+		"arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890",
+		"arn:aws:iam::210987654321:user/alice",
+	)
+	require.Equal(t, expectedARNs, event.PantherAnyAWSARNs)
+	expectedAccounts := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedAccounts, "123456789012", "210987654321")
+	require.Equal(t, expectedAccounts, event.PantherAnyAWSAccountIds)
+	expectedInstances := parsers.NewPantherAnyString()
+	parsers.AppendAnyString(expectedInstances, "i-0abcdef1234567890")
+	require.Equal(t, expectedInstances, event.PantherAnyAWSInstanceIds)
+	require.Nil(t, event.PantherAnyAWSTags)
+
+	event = AWSPantherLog{}
+	event.ScanARNs("not-an-arn")
+	require.Nil(t, event.PantherAnyAWSARNs)
+	require.Nil(t, event.PantherAnyAWSAccountIds)
+}
+
+func TestFieldMetaRegistered(t *testing.T) {
+	byJSONName := pantherlog.FieldMetaByJSONName()
+	byID := pantherlog.FieldMetaByID()
+	for id, nameJSON := range map[pantherlog.FieldID]string{
+		FieldAccountID:          "p_any_aws_account_ids",
+		FieldARN:                "p_any_aws_arns",
+		FieldTag:                "p_any_aws_tags",
+		FieldInstanceID:         "p_any_aws_instance_ids",
+		FieldResourceName:       "p_any_aws_resource_names",
+		FieldAMIID:              "p_any_aws_ami_ids",
+		FieldVolumeID:           "p_any_aws_volume_ids",
+		FieldSnapshotID:         "p_any_aws_snapshot_ids",
+		FieldNetworkInterfaceID: "p_any_aws_network_interface_ids",
+		FieldSecurityGroupID:    "p_any_aws_security_group_ids",
+		FieldKMSKeyID:           "p_any_aws_kms_key_ids",
+		FieldLogGroup:           "p_any_aws_log_groups",
+		FieldPartition:          "p_any_aws_partitions",
+	} {
+		meta, ok := byJSONName[nameJSON]
+		require.True(t, ok, nameJSON)
+		require.Equal(t, nameJSON, meta.NameJSON)
+		require.NotEmpty(t, meta.Description, nameJSON)
+		require.Equal(t, meta, byID[id], nameJSON)
+	}
+	require.Equal(t, "p_any_ip_addresses", byID[pantherlog.FieldIPAddress].NameJSON)
 }
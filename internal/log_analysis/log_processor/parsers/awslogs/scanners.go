@@ -0,0 +1,725 @@
+package awslogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
+)
+
+// ScanARN scans an ARN string for the ARN, account id, region and instance id values.
+// Invalid ARNs are ignored.
+func ScanARN(w pantherlog.ValueWriter, input string) {
+	// value based matching
+	if !strings.HasPrefix(input, "arn:") {
+		return
+	}
+	/* arns may contain an embedded account id as well as interesting resources
+	   See: https://docs.aws.amazon.com/general/latest/gr/aws-arns-and-namespaces.html
+	   Formats:
+	    arn:partition:service:region:account-id:resource-id
+	    arn:partition:service:region:account-id:resource-type/resource-id
+	    arn:partition:service:region:account-id:resource-type:resource-id
+	*/
+	parsedARN, err := arn.Parse(input)
+	if err != nil {
+		return
+	}
+	w.WriteValues(FieldARN, input)
+	ScanAccountID(w, parsedARN.AccountID)
+	ScanPartition(w, parsedARN.Partition)
+	ScanRegion(w, parsedARN.Region)
+	scanResourceEC2ID(w, parsedARN.Resource)
+	switch parsedARN.Service {
+	case "elasticloadbalancing":
+		scanResourceLoadBalancer(w, parsedARN.Resource)
+	case "cloudformation":
+		scanResourceStack(w, parsedARN.Resource)
+	case "s3":
+		scanResourceS3Bucket(w, parsedARN)
+	case "iam", "sts":
+		scanResourceIAMName(w, parsedARN.Resource)
+	case "kms":
+		scanResourceKMSKey(w, parsedARN.Resource)
+	case "logs":
+		scanResourceLogGroup(w, parsedARN.Resource)
+	}
+}
+
+// ScanARNShort scans an ARN string like ScanARN and also writes its region independent short form
+// `<service>:<account-id>:<resource>` so that references to the same resource with or without a region match.
+func ScanARNShort(w pantherlog.ValueWriter, input string) {
+	if !strings.HasPrefix(input, "arn:") {
+		return
+	}
+	parsedARN, err := arn.Parse(input)
+	if err != nil {
+		return
+	}
+	ScanARN(w, input)
+	w.WriteValues(FieldARNShort, parsedARN.Service+":"+parsedARN.AccountID+":"+parsedARN.Resource)
+}
+
+// EC2 resources are `<resource-type>/<id>` (ie `instance/i-0abcdef1234567890`).
+// See: https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/iam-policy-structure.html#EC2_ARN_Format
+func scanResourceEC2ID(w pantherlog.ValueWriter, resource string) {
+	resourceType, id, ok := splitResource(resource)
+	if !ok {
+		return
+	}
+	prefix, ok := ec2ResourceTypePrefixes[resourceType]
+	if !ok {
+		return
+	}
+	if strings.HasPrefix(id, prefix+"-") {
+		ScanEC2ResourceID(w, id)
+	}
+}
+
+// splitResource splits an ARN resource in `<resource-type>/<id>` or `<resource-type>:<id>` form
+// on whichever separator comes first, so that ids containing the other separator
+// (ie `log-group:/aws/lambda/instance/i-0abcdef1234567890`) are kept whole.
+func splitResource(resource string) (resourceType, id string, ok bool) {
+	pos := strings.IndexAny(resource, ":/")
+	if pos == -1 {
+		return "", "", false
+	}
+	return resource[:pos], resource[pos+1:], true
+}
+
+// EC2 ARN resource types and the prefix of their resource ids
+var ec2ResourceTypePrefixes = map[string]string{
+	"instance":          "i",
+	"image":             "ami",
+	"volume":            "vol",
+	"snapshot":          "snap",
+	"network-interface": "eni",
+	"security-group":    "sg",
+}
+
+// Load balancer resources are `loadbalancer/<name>` (classic) or `loadbalancer/{app,net}/<name>/<id>`.
+// Target group resources are `targetgroup/<name>/<id>`.
+// See: https://docs.aws.amazon.com/elasticloadbalancing/latest/userguide/load-balancer-authentication-access-control.html
+func scanResourceLoadBalancer(w pantherlog.ValueWriter, resource string) {
+	parts := strings.Split(resource, "/")
+	switch {
+	case parts[0] == "loadbalancer" && len(parts) == 2:
+		w.WriteValues(FieldLoadBalancerName, parts[1])
+	case parts[0] == "loadbalancer" && len(parts) == 4:
+		w.WriteValues(FieldLoadBalancerName, parts[2])
+	case parts[0] == "targetgroup" && len(parts) == 3:
+		w.WriteValues(FieldTargetGroupName, parts[1])
+	}
+}
+
+// Load balancer and target group names have up to 32 alphanumeric characters or hyphens
+var elbNameRegex = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,30}[a-zA-Z0-9])?$`)
+
+// ScanLoadBalancerName scans a load balancer name or ARN
+func ScanLoadBalancerName(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	if elbNameRegex.MatchString(input) {
+		w.WriteValues(FieldLoadBalancerName, input)
+	}
+}
+
+// ScanTargetGroupName scans a load balancer target group name or ARN
+func ScanTargetGroupName(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	if elbNameRegex.MatchString(input) {
+		w.WriteValues(FieldTargetGroupName, input)
+	}
+}
+
+// Stack resources are `stack/<name>/<id>`.
+// See: https://docs.aws.amazon.com/IAM/latest/UserGuide/list_awscloudformation.html#awscloudformation-resources-for-iam-policies
+func scanResourceStack(w pantherlog.ValueWriter, resource string) {
+	parts := strings.Split(resource, "/")
+	if len(parts) == 3 && parts[0] == "stack" && stackNameRegex.MatchString(parts[1]) {
+		w.WriteValues(FieldStackName, parts[1])
+	}
+}
+
+// Stack names start with a letter and have up to 128 alphanumeric characters or hyphens
+var stackNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,127}$`)
+
+// ScanStackName scans a CloudFormation stack name or stack ARN
+func ScanStackName(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	if stackNameRegex.MatchString(input) {
+		w.WriteValues(FieldStackName, input)
+	}
+}
+
+// ScanAccountID scans a 12-digit AWS account id.
+// Whitespace around the id is trimmed, so ` 123456789012 ` is scanned as `123456789012`.
+func ScanAccountID(w pantherlog.ValueWriter, input string) {
+	if id, ok := normalizeAccountID(input, false); ok {
+		w.WriteValues(FieldAccountID, id)
+	}
+}
+
+// ScanAccountIDStrict scans an account id like ScanAccountID,
+// but also rejects the `000000000000` placeholder some tools emit for unresolved accounts.
+func ScanAccountIDStrict(w pantherlog.ValueWriter, input string) {
+	if id, ok := normalizeAccountID(input, true); ok {
+		w.WriteValues(FieldAccountID, id)
+	}
+}
+
+// placeholderAccountID is used by some tools for accounts they could not resolve
+const placeholderAccountID = "000000000000"
+
+// normalizeAccountID trims whitespace around an account id and checks it is 12 digits.
+// In strict mode the all-zeros placeholder account id is rejected.
+func normalizeAccountID(id string, strict bool) (string, bool) {
+	id = strings.TrimSpace(id)
+	if !awsAccountIDRegex.MatchString(id) {
+		return "", false
+	}
+	if strict && id == placeholderAccountID {
+		return "", false
+	}
+	return id, true
+}
+
+// S3 bucket and object resources are `<bucket>` or `<bucket>/<key>` and have no region or account id.
+// Other S3 resources (ie `accesspoint/<name>`) have both.
+// See: https://docs.aws.amazon.com/AmazonS3/latest/dev/s3-arn-format.html
+func scanResourceS3Bucket(w pantherlog.ValueWriter, parsedARN arn.ARN) {
+	if parsedARN.Region != "" || parsedARN.AccountID != "" {
+		return
+	}
+	bucket := parsedARN.Resource
+	if pos := strings.IndexByte(bucket, '/'); pos != -1 {
+		bucket = bucket[:pos]
+	}
+	if isS3BucketName(bucket) {
+		w.WriteValues(FieldS3Bucket, bucket)
+	}
+}
+
+var (
+	// Bucket names have 3 to 63 lowercase letters, numbers, dots or hyphens and start and end with a letter or number
+	s3BucketNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	ipAddressRegex    = regexp.MustCompile(`^\d+\.\d+\.\d+\.\d+$`)
+)
+
+// See: https://docs.aws.amazon.com/AmazonS3/latest/dev/BucketRestrictions.html#bucketnamingrules
+func isS3BucketName(name string) bool {
+	return s3BucketNameRegex.MatchString(name) && !strings.Contains(name, "..") && !ipAddressRegex.MatchString(name)
+}
+
+// ScanS3Bucket scans a DNS-compliant S3 bucket name or an S3 ARN
+func ScanS3Bucket(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	if isS3BucketName(input) {
+		w.WriteValues(FieldS3Bucket, input)
+	}
+}
+
+var (
+	// IAM user and role names (ie `MyRole`)
+	iamNameRegex = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+	// Role session names (ie `botocore-session-1577923200`)
+	roleSessionNameRegex = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+)
+
+// IAM resources are `role/<path>/<name>` and `user/<path>/<name>` where the path is optional.
+// STS assumed role resources are `assumed-role/<role>/<session>`.
+// See: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_identifiers.html#identifiers-arns
+func scanResourceIAMName(w pantherlog.ValueWriter, resource string) {
+	parts := strings.Split(resource, "/")
+	switch {
+	case len(parts) >= 2 && (parts[0] == "role" || parts[0] == "user"):
+		if name := parts[len(parts)-1]; iamNameRegex.MatchString(name) {
+			w.WriteValues(FieldIAMName, name)
+		}
+	case len(parts) == 3 && parts[0] == "assumed-role":
+		if iamNameRegex.MatchString(parts[1]) {
+			w.WriteValues(FieldIAMName, parts[1])
+		}
+		if roleSessionNameRegex.MatchString(parts[2]) {
+			w.WriteValues(FieldSessionName, parts[2])
+		}
+	}
+}
+
+// ScanIAMName scans an IAM role or user name or an IAM/STS ARN
+func ScanIAMName(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	if iamNameRegex.MatchString(input) {
+		w.WriteValues(FieldIAMName, input)
+	}
+}
+
+// Region names (ie `us-east-1`, `us-gov-west-1`, `cn-north-1`, `us-iso-east-1`)
+var regionRegex = regexp.MustCompile(`^[a-z]{2}(?:-gov|-isob?)?-[a-z]+-\d$`)
+
+// AWS partitions
+// See: https://docs.aws.amazon.com/general/latest/gr/aws-arns-and-namespaces.html
+var awsPartitions = map[string]bool{
+	"aws":        true,
+	"aws-cn":     true,
+	"aws-us-gov": true,
+	"aws-iso":    true,
+	"aws-iso-b":  true,
+}
+
+// ScanPartition scans an AWS partition name (ie `aws`, `aws-cn` or `aws-us-gov`)
+func ScanPartition(w pantherlog.ValueWriter, input string) {
+	if awsPartitions[input] {
+		w.WriteValues(FieldPartition, input)
+	}
+}
+
+// ScanRegion scans an AWS region name
+func ScanRegion(w pantherlog.ValueWriter, input string) {
+	if regionRegex.MatchString(input) {
+		w.WriteValues(FieldRegion, input)
+	}
+}
+
+// ScanInstanceID scans an EC2 instance id (`i-` prefix).
+// Unlike ScanEC2ResourceID it only checks the prefix, as AppendAnyAWSInstanceIds does.
+func ScanInstanceID(w pantherlog.ValueWriter, input string) {
+	if isInstanceID(input) {
+		w.WriteValues(FieldInstanceID, input)
+	}
+}
+
+// isInstanceID checks for the `i-` prefix of an EC2 instance id
+func isInstanceID(id string) bool {
+	return len(id) > len("i-") && strings.HasPrefix(id, "i-")
+}
+
+// ScanSecurityGroupID scans an EC2 security group id (`sg-` prefix)
+func ScanSecurityGroupID(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "sg-") {
+		ScanEC2ResourceID(w, input)
+	}
+}
+
+// EC2 resource id prefixes and the field for each
+// See: https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/resource-ids.html
+var ec2ResourceIDFields = map[string]pantherlog.FieldID{
+	"i":    FieldInstanceID,
+	"ami":  FieldAMIID,
+	"vol":  FieldVolumeID,
+	"snap": FieldSnapshotID,
+	"eni":  FieldNetworkInterfaceID,
+	"sg":   FieldSecurityGroupID,
+}
+
+// ScanEC2ResourceID scans an EC2 resource id in `<prefix>-<hex>` form (ie `ami-0abcdef1234567890`).
+// The id is written to the field of its prefix. Ids with an unknown prefix are ignored.
+func ScanEC2ResourceID(w pantherlog.ValueWriter, input string) {
+	pos := strings.IndexByte(input, '-')
+	if pos == -1 {
+		return
+	}
+	field, ok := ec2ResourceIDFields[input[:pos]]
+	if !ok || !isEC2HexID(input[pos+1:]) {
+		return
+	}
+	w.WriteValues(field, input)
+}
+
+// isEC2HexID checks the hex part of an EC2 resource id, 8 (older ids) or 17 lowercase hex digits.
+func isEC2HexID(id string) bool {
+	if len(id) != 8 && len(id) != 17 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Limits on the length of AWS tag keys and values
+const (
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+// ScanTag scans an AWS tag in `key:value` form.
+// The key is required, the value can be empty. Whitespace around the key is trimmed.
+func ScanTag(w pantherlog.ValueWriter, input string) {
+	if tag, ok := normalizeTag(input); ok {
+		w.WriteValues(FieldTag, tag)
+	}
+}
+
+// normalizeTag checks an AWS tag in `key:value` form and trims whitespace around the key.
+// The tag is split on the first colon so values can contain colons (ie ARNs).
+func normalizeTag(tag string) (string, bool) {
+	pos := strings.IndexByte(tag, ':')
+	if pos == -1 {
+		return "", false
+	}
+	key, value := strings.TrimSpace(tag[:pos]), tag[pos+1:]
+	if key == "" || len(key) > maxTagKeyLength || len(value) > maxTagValueLength {
+		return "", false
+	}
+	if len(key) == pos {
+		return tag, true
+	}
+	return key + ":" + value, true
+}
+
+// tagValue returns the value of an AWS tag in `key:value` form, checking the key and value lengths.
+func tagValue(tag string) (string, bool) {
+	tag, ok := normalizeTag(tag)
+	if !ok {
+		return "", false
+	}
+	return tag[strings.IndexByte(tag, ':')+1:], true
+}
+
+// ScanTagIdentifiers scans an AWS tag in `key:value` form like ScanTag.
+// If the tag value is an ARN, an instance id or an account id (ie `owner:arn:aws:iam::123456789012:user/alice`)
+// it is also scanned into its own fields. Only the tag value is scanned, it is not split as a tag again.
+func ScanTagIdentifiers(w pantherlog.ValueWriter, input string) {
+	tag, ok := normalizeTag(input)
+	if !ok {
+		return
+	}
+	w.WriteValues(FieldTag, tag)
+	value := tag[strings.IndexByte(tag, ':')+1:]
+	switch {
+	case strings.HasPrefix(value, "arn:"):
+		ScanARN(w, value)
+	case strings.HasPrefix(value, "i-"):
+		ScanInstanceID(w, value)
+	default:
+		ScanAccountID(w, value)
+	}
+}
+
+// ScanTagResourceName scans an AWS tag in `key:value` form like ScanTag.
+// If the tag is a `Name` tag, its value is also written as a resource name.
+func ScanTagResourceName(w pantherlog.ValueWriter, input string) {
+	tag, ok := normalizeTag(input)
+	if !ok {
+		return
+	}
+	w.WriteValues(FieldTag, tag)
+	if name, ok := nameTagValue(tag); ok {
+		w.WriteValues(FieldResourceName, name)
+	}
+}
+
+// nameTagValue returns the value of a `Name:<value>` tag
+func nameTagValue(tag string) (string, bool) {
+	const namePrefix = "Name:"
+	if !strings.HasPrefix(tag, namePrefix) {
+		return "", false
+	}
+	name := tag[len(namePrefix):]
+	return name, name != ""
+}
+
+var (
+	// See https://github.com/docker/distribution/blob/master/reference/regexp.go
+	imageRepositoryRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	imageTagRegex        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRegex     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	ecrRegistryRegex     = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+)
+
+const defaultImageRegistry = "docker.io"
+
+// ScanImageRef scans a container image reference (ie `<registry>/<repository>:<tag>@<digest>`).
+// References are normalized to include the registry (`docker.io/library/` for official Docker Hub images).
+// For images hosted in ECR it also scans the account id and region of the registry.
+func ScanImageRef(w pantherlog.ValueWriter, input string) {
+	ref := strings.TrimSpace(input)
+	var digest string
+	if pos := strings.IndexByte(ref, '@'); pos != -1 {
+		ref, digest = ref[:pos], ref[pos+1:]
+		if !imageDigestRegex.MatchString(digest) {
+			return
+		}
+	}
+	var registry string
+	if pos := strings.IndexByte(ref, '/'); pos != -1 {
+		// The first component is a registry if it looks like a host name
+		if host := ref[:pos]; host == "localhost" || strings.ContainsAny(host, ".:") {
+			registry, ref = strings.ToLower(host), ref[pos+1:]
+		}
+	}
+	var tag string
+	if pos := strings.LastIndexByte(ref, ':'); pos != -1 {
+		ref, tag = ref[:pos], ref[pos+1:]
+		if !imageTagRegex.MatchString(tag) {
+			return
+		}
+	}
+	if !imageRepositoryRegex.MatchString(ref) {
+		return
+	}
+	if registry == "" {
+		registry = defaultImageRegistry
+		if !strings.Contains(ref, "/") {
+			ref = "library/" + ref
+		}
+	}
+
+	normalized := registry + "/" + ref
+	if tag != "" {
+		normalized += ":" + tag
+	}
+	if digest != "" {
+		normalized += "@" + digest
+	}
+	w.WriteValues(FieldImageRef, normalized)
+
+	if match := ecrRegistryRegex.FindStringSubmatch(registry); match != nil {
+		w.WriteValues(FieldAccountID, match[1])
+		w.WriteValues(FieldRegion, match[2])
+	}
+}
+
+var (
+	kmsGrantIDRegex = regexp.MustCompile(`^[a-f0-9]{64}$`)
+	// See https://docs.aws.amazon.com/kms/latest/developerguide/concepts.html#key-id
+	kmsKeyIDRegex = regexp.MustCompile(`^(?:[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}|mrk-[a-f0-9]{32})$`)
+	kmsAliasRegex = regexp.MustCompile(`^alias/[\w/-]{1,250}$`)
+	// See https://docs.aws.amazon.com/awscloudtrail/latest/userguide/cloudtrail-log-file-validation-digest-file-structure.html
+	cloudTrailFileRegex = regexp.MustCompile(`^(\d{12})_CloudTrail(?:-Digest)?_([a-z0-9-]+)_[\w.-]+\.json(?:\.gz)?$`)
+)
+
+// ScanKMSGrantID scans a KMS grant id (64 hex digits)
+func ScanKMSGrantID(w pantherlog.ValueWriter, input string) {
+	if kmsGrantIDRegex.MatchString(input) {
+		w.WriteValues(FieldKMSGrantID, input)
+	}
+}
+
+// ScanKMSKey scans a KMS key id (UUID or multi-region `mrk-` form), a key alias (`alias/<name>`) or a KMS ARN
+func ScanKMSKey(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	if kmsKeyIDRegex.MatchString(input) || kmsAliasRegex.MatchString(input) {
+		w.WriteValues(FieldKMSKeyID, input)
+	}
+}
+
+// KMS resources are `key/<key-id>` or `alias/<name>`
+// See: https://docs.aws.amazon.com/kms/latest/developerguide/concepts.html#key-id-key-ARN
+func scanResourceKMSKey(w pantherlog.ValueWriter, resource string) {
+	switch {
+	case strings.HasPrefix(resource, "key/"):
+		ScanKMSKey(w, strings.TrimPrefix(resource, "key/"))
+	case strings.HasPrefix(resource, "alias/"):
+		ScanKMSKey(w, resource)
+	}
+}
+
+// See https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_CreateLogGroup.html
+var logGroupNameRegex = regexp.MustCompile(`^[\w.\-/#]{1,512}$`)
+
+// ScanLogGroup scans a CloudWatch Logs log group name or ARN.
+// A trailing `:*` wildcard (ie `/aws/lambda/my-func:*`) is stripped from the name.
+func ScanLogGroup(w pantherlog.ValueWriter, input string) {
+	if strings.HasPrefix(input, "arn:") {
+		ScanARN(w, input)
+		return
+	}
+	name := strings.TrimSuffix(input, ":*")
+	if logGroupNameRegex.MatchString(name) {
+		w.WriteValues(FieldLogGroup, name)
+	}
+}
+
+// CloudWatch Logs resources are `log-group:<name>`, `log-group:<name>:*` or `log-group:<name>:log-stream:<stream>`
+// See: https://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/iam-access-control-overview-cwl.html
+func scanResourceLogGroup(w pantherlog.ValueWriter, resource string) {
+	resourceType, name, ok := splitResource(resource)
+	if !ok || resourceType != "log-group" {
+		return
+	}
+	if pos := strings.Index(name, ":log-stream:"); pos != -1 {
+		name = name[:pos]
+	}
+	ScanLogGroup(w, name)
+}
+
+// ScanCloudTrailFile scans the file name of a CloudTrail log or digest file from an S3 object key.
+// It also scans the account id and region embedded in the file name.
+func ScanCloudTrailFile(w pantherlog.ValueWriter, input string) {
+	name := input[strings.LastIndexByte(input, '/')+1:]
+	match := cloudTrailFileRegex.FindStringSubmatch(name)
+	if match == nil {
+		return
+	}
+	w.WriteValues(FieldCloudTrailFile, name)
+	w.WriteValues(FieldAccountID, match[1])
+	w.WriteValues(FieldRegion, match[2])
+}
+
+var (
+	// AZ names are the region followed by a letter (ie `us-east-1a`, `us-west-2-lax-1a` for local zones)
+	availabilityZoneNameRegex = regexp.MustCompile(`^([a-z]{2}(?:-gov|-isob?)?-[a-z]+-\d)(?:-[a-z]+-\d)?[a-z]$`)
+	// AZ ids are consistent across accounts (ie `use1-az1`)
+	availabilityZoneIDRegex = regexp.MustCompile(`^[a-z]{2,4}\d-(?:[a-z]+\d-)?az\d+$`)
+)
+
+// ScanAvailabilityZone scans an availability zone name or id.
+// For availability zone names it also scans the region.
+func ScanAvailabilityZone(w pantherlog.ValueWriter, input string) {
+	if match := availabilityZoneNameRegex.FindStringSubmatch(input); match != nil {
+		w.WriteValues(FieldAvailabilityZone, input)
+		w.WriteValues(FieldRegion, match[1])
+		return
+	}
+	if availabilityZoneIDRegex.MatchString(input) {
+		w.WriteValues(FieldAvailabilityZone, input)
+	}
+}
+
+// Route53 Resolver endpoint ids (ie `rslvr-in-1a2b3c4d5e6f7g8h9`)
+var resolverEndpointIDRegex = regexp.MustCompile(`^rslvr-(?:in|out)-[a-z0-9]{17}$`)
+
+// ScanResolverEndpointID scans a Route53 Resolver inbound or outbound endpoint id.
+func ScanResolverEndpointID(w pantherlog.ValueWriter, input string) {
+	if resolverEndpointIDRegex.MatchString(input) {
+		w.WriteValues(FieldResolverEndpointID, input)
+	}
+}
+
+// ScanDNSQueryName scans the query name of a DNS query (ie `example.com.`) as a domain name.
+// The trailing dot of fully qualified names is removed.
+func ScanDNSQueryName(w pantherlog.ValueWriter, input string) {
+	input = strings.TrimSuffix(input, ".")
+	if input == "" {
+		return
+	}
+	pantherlog.ScanHostname(w, input)
+}
+
+// GuardDuty finding types have a `ThreatPurpose:ResourceTypeAffected/ThreatFamilyName.DetectionMechanism!Artifact` form
+// (ie `Recon:EC2/PortProbeUnprotectedPort`, `Backdoor:EC2/C&CActivity.B!DNS`).
+// See https://docs.aws.amazon.com/guardduty/latest/ug/guardduty_finding-format.html
+var findingTypeRegex = regexp.MustCompile(`^([A-Za-z]+):[A-Za-z0-9]+/[\w.!&-]+$`)
+
+// ScanFindingType scans a GuardDuty finding type.
+// It also scans the top-level category (threat purpose) of the finding type (ie `Recon`).
+func ScanFindingType(w pantherlog.ValueWriter, input string) {
+	match := findingTypeRegex.FindStringSubmatch(input)
+	if match == nil {
+		return
+	}
+	w.WriteValues(FieldFindingType, input)
+	w.WriteValues(FieldFindingCategory, match[1])
+}
+
+// Normalized severity values
+const (
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// ScanSeverity scans a finding severity and normalizes it to LOW, MEDIUM, HIGH or CRITICAL.
+// Numeric severities use the GuardDuty ranges (ie `0` to `3.9` is LOW, `4.0` to `6.9` is MEDIUM,
+// `7.0` to `8.9` is HIGH and `9.0` to `10.0` is CRITICAL).
+// String severities (ie `High`) are matched case-insensitively, other values are ignored.
+// See https://docs.aws.amazon.com/guardduty/latest/ug/guardduty_findings.html#guardduty_findings-severity
+func ScanSeverity(w pantherlog.ValueWriter, input string) {
+	input = strings.TrimSpace(input)
+	severity := strings.ToUpper(input)
+	switch severity {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+	default:
+		n, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return
+		}
+		switch {
+		case !(n >= 0 && n <= 10): // also rejects NaN
+			return
+		case n < 4:
+			severity = SeverityLow
+		case n < 7:
+			severity = SeverityMedium
+		case n < 9:
+			severity = SeverityHigh
+		default:
+			severity = SeverityCritical
+		}
+	}
+	w.WriteValues(FieldSeverity, severity)
+}
+
+// Access key ids and IAM unique ids have a 4-letter prefix identifying the kind of resource
+// (ie `AKIA` for access keys, `ASIA` for temporary access keys, `AIDA` for users and `AROA` for roles).
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_identifiers.html#identifiers-unique-ids
+var accessKeyIDRegex = regexp.MustCompile(`^A(?:KIA|SIA|IDA|ROA|IPA|NPA|NVA|GPA|PKA|BIA|CCA)[A-Z0-9]{16,17}$`)
+
+// ScanPrincipalID scans a CloudTrail `userIdentity.principalId`.
+// Principal ids are a unique id (ie `AIDACKCEVSQ6C2EXAMPLE`), a unique id and a session name for assumed roles
+// (ie `AROACKCEVSQ6C2EXAMPLE:session-name`) or an account id and a name for root and federated users
+// (ie `123456789012:user`).
+// The unique id is scanned as an access key id and the name as a session name.
+func ScanPrincipalID(w pantherlog.ValueWriter, input string) {
+	id, name := input, ""
+	if pos := strings.IndexByte(input, ':'); pos != -1 {
+		id, name = input[:pos], input[pos+1:]
+	}
+	switch {
+	case accessKeyIDRegex.MatchString(id):
+		w.WriteValues(FieldAccessKeyID, id)
+	case awsAccountIDRegex.MatchString(id):
+		w.WriteValues(FieldAccountID, id)
+	default:
+		return
+	}
+	w.WriteValues(FieldPrincipalID, input)
+	if name != "" {
+		w.WriteValues(FieldSessionName, name)
+	}
+}
@@ -0,0 +1,661 @@
+package awslogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
+)
+
+// helper to collect the values written by a scanner
+func TestScanARN(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:        {"arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890"},
+		FieldPartition:  {"aws"},
+		FieldAccountID:  {"123456789012"},
+		FieldRegion:     {"us-east-1"},
+		FieldInstanceID: {"i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws-us-gov:sns:us-gov-west-1:123456789012:topic"},
+		FieldPartition: {"aws-us-gov"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-gov-west-1"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws-us-gov:sns:us-gov-west-1:123456789012:topic"))
+	require.Nil(t, pantherlog.InspectScan(ScanARN, "arn:foo"))
+}
+
+func TestScanARNPartitions(t *testing.T) {
+	for _, tc := range []struct {
+		ARN       string
+		Partition string
+		Region    string
+	}{
+		{"arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890", "aws", "us-east-1"},
+		{"arn:aws-cn:ec2:cn-northwest-1:123456789012:instance/i-0abcdef1234567890", "aws-cn", "cn-northwest-1"},
+		{"arn:aws-us-gov:ec2:us-gov-west-1:123456789012:instance/i-0abcdef1234567890", "aws-us-gov", "us-gov-west-1"},
+		{"arn:aws-iso:ec2:us-iso-east-1:123456789012:instance/i-0abcdef1234567890", "aws-iso", "us-iso-east-1"},
+	} {
+		require.Equal(t, map[pantherlog.FieldID][]string{
+			FieldARN:        {tc.ARN},
+			FieldPartition:  {tc.Partition},
+			FieldAccountID:  {"123456789012"},
+			FieldRegion:     {tc.Region},
+			FieldInstanceID: {"i-0abcdef1234567890"},
+		}, pantherlog.InspectScan(ScanARN, tc.ARN), tc.ARN)
+	}
+	// unknown partitions are not written
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:foo:sns:us-east-1:123456789012:topic"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+	}, pantherlog.InspectScan(ScanARN, "arn:foo:sns:us-east-1:123456789012:topic"))
+	require.Nil(t, pantherlog.InspectScan(ScanPartition, "aws-gov"))
+	require.Nil(t, pantherlog.InspectScan(ScanPartition, ""))
+}
+
+func TestScanAccountID(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAccountID: {"123456789012"},
+	}, pantherlog.InspectScan(ScanAccountID, " 123456789012\t"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAccountID: {"000000000000"},
+	}, pantherlog.InspectScan(ScanAccountID, "000000000000"))
+	require.Nil(t, pantherlog.InspectScan(ScanAccountID, "12345678901"))
+	require.Nil(t, pantherlog.InspectScan(ScanAccountID, "1234 5678 9012"))
+
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAccountID: {"123456789012"},
+	}, pantherlog.InspectScan(ScanAccountIDStrict, " 123456789012"))
+	require.Nil(t, pantherlog.InspectScan(ScanAccountIDStrict, "000000000000"))
+	require.Nil(t, pantherlog.InspectScan(ScanAccountIDStrict, " 000000000000 "))
+}
+
+func TestScanS3Bucket(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:s3:::panther-data/logs/2020/01/02/file.json.gz"},
+		FieldPartition: {"aws"},
+		FieldS3Bucket:  {"panther-data"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:s3:::panther-data/logs/2020/01/02/file.json.gz"))
+	require.Equal(t,
+		pantherlog.InspectScan(ScanARN, "arn:aws:s3:::panther-data"),
+		pantherlog.InspectScan(ScanS3Bucket, "arn:aws:s3:::panther-data"))
+	// Access points are not buckets
+	require.Nil(t, pantherlog.InspectScan(ScanARN, "arn:aws:s3:us-west-2:123456789012:accesspoint/my-ap")[FieldS3Bucket])
+
+	for _, bucket := range []string{"abc", "panther-data", "my.bucket.name", "123bucket", strings.Repeat("a", 63)} {
+		require.Equal(t, map[pantherlog.FieldID][]string{
+			FieldS3Bucket: {bucket},
+		}, pantherlog.InspectScan(ScanS3Bucket, bucket), bucket)
+	}
+	for _, input := range []string{
+		"ab",
+		strings.Repeat("a", 64),
+		"Panther-Data",
+		"my..bucket",
+		"-bucket",
+		"bucket-",
+		".bucket",
+		"192.168.5.4",
+		"my_bucket",
+		"",
+	} {
+		require.Nil(t, pantherlog.InspectScan(ScanS3Bucket, input), input)
+	}
+}
+
+func TestScanIAMName(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:iam::123456789012:role/admin"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldIAMName:   {"admin"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:iam::123456789012:role/admin"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:iam::123456789012:role/path/to/MyRole"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldIAMName:   {"MyRole"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:iam::123456789012:role/path/to/MyRole"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:iam::123456789012:user/alice@example.com"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldIAMName:   {"alice@example.com"},
+	}, pantherlog.InspectScan(ScanIAMName, "arn:aws:iam::123456789012:user/alice@example.com"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:         {"arn:aws:sts::123456789012:assumed-role/PantherRole/session-name"},
+		FieldPartition:   {"aws"},
+		FieldAccountID:   {"123456789012"},
+		FieldIAMName:     {"PantherRole"},
+		FieldSessionName: {"session-name"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:sts::123456789012:assumed-role/PantherRole/session-name"))
+	// Other IAM resources
+	require.Nil(t, pantherlog.InspectScan(ScanARN, "arn:aws:iam::123456789012:policy/MyPolicy")[FieldIAMName])
+	require.Nil(t, pantherlog.InspectScan(ScanARN, "arn:aws:iam::123456789012:root")[FieldIAMName])
+	require.Nil(t, pantherlog.InspectScan(ScanARN, "arn:aws:iam::123456789012:role/")[FieldIAMName])
+
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldIAMName: {"MyRole"},
+	}, pantherlog.InspectScan(ScanIAMName, "MyRole"))
+	require.Nil(t, pantherlog.InspectScan(ScanIAMName, "my role"))
+	require.Nil(t, pantherlog.InspectScan(ScanIAMName, strings.Repeat("a", 65)))
+}
+
+func TestScanEC2ResourceID(t *testing.T) {
+	for input, field := range map[string]pantherlog.FieldID{
+		"i-0abcdef1234567890":    FieldInstanceID,
+		"i-99999999":             FieldInstanceID,
+		"ami-0abcdef1234567890":  FieldAMIID,
+		"vol-049df61146c4d7901":  FieldVolumeID,
+		"snap-1234567890abcdef0": FieldSnapshotID,
+		"eni-e5aa89a3":           FieldNetworkInterfaceID,
+	} {
+		require.Equal(t, map[pantherlog.FieldID][]string{
+			field: {input},
+		}, pantherlog.InspectScan(ScanEC2ResourceID, input), input)
+	}
+	for _, input := range []string{
+		"",
+		"ami-",
+		"ami-0abcdef",
+		"ami-0ABCDEF1234567890",
+		"ami-0abcdef12345678901",
+		"foo-0abcdef1234567890",
+		"0abcdef1234567890",
+	} {
+		require.Nil(t, pantherlog.InspectScan(ScanEC2ResourceID, input), input)
+	}
+	// ScanInstanceID only checks the prefix
+	require.Nil(t, pantherlog.InspectScan(ScanInstanceID, "ami-0abcdef1234567890"))
+	require.Nil(t, pantherlog.InspectScan(ScanInstanceID, "i-"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldInstanceID: {"i-abc"},
+	}, pantherlog.InspectScan(ScanInstanceID, "i-abc"))
+}
+
+func TestScanARNColonResource(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:lambda:us-east-1:123456789012:function:instance:i-0abcdef1234567890"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:lambda:us-east-1:123456789012:function:instance:i-0abcdef1234567890"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/instance/i-0abcdef1234567890"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldLogGroup:  {"/aws/lambda/instance/i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/instance/i-0abcdef1234567890"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:logs:us-east-1:123456789012:log-group:instance/i-0abcdef1234567890:*"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldLogGroup:  {"instance/i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:logs:us-east-1:123456789012:log-group:instance/i-0abcdef1234567890:*"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:dynamodb:us-east-1:123456789012:table/instance/stream/2020-01-01T00:00:00.000"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:dynamodb:us-east-1:123456789012:table/instance/stream/2020-01-01T00:00:00.000"))
+	// a colon separated resource type is still matched
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:        {"arn:aws:ec2:us-east-1:123456789012:instance:i-0abcdef1234567890"},
+		FieldPartition:  {"aws"},
+		FieldAccountID:  {"123456789012"},
+		FieldRegion:     {"us-east-1"},
+		FieldInstanceID: {"i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:ec2:us-east-1:123456789012:instance:i-0abcdef1234567890"))
+}
+
+func TestScanSecurityGroupID(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldSecurityGroupID: {"sg-0123abcd"},
+	}, pantherlog.InspectScan(ScanSecurityGroupID, "sg-0123abcd"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldSecurityGroupID: {"sg-0123456789abcdef0"},
+	}, pantherlog.InspectScan(ScanSecurityGroupID, "sg-0123456789abcdef0"))
+	require.Nil(t, pantherlog.InspectScan(ScanSecurityGroupID, "sg-0123"))
+	require.Nil(t, pantherlog.InspectScan(ScanSecurityGroupID, "sg-web-servers"))
+	require.Nil(t, pantherlog.InspectScan(ScanSecurityGroupID, "i-0abcdef1234567890"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:             {"arn:aws:ec2:us-east-1:123456789012:security-group/sg-0123abcd"},
+		FieldPartition:       {"aws"},
+		FieldAccountID:       {"123456789012"},
+		FieldRegion:          {"us-east-1"},
+		FieldSecurityGroupID: {"sg-0123abcd"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:ec2:us-east-1:123456789012:security-group/sg-0123abcd"))
+}
+
+func TestScanARNEC2ResourceID(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:ec2:us-east-1::image/ami-0abcdef1234567890"},
+		FieldPartition: {"aws"},
+		FieldRegion:    {"us-east-1"},
+		FieldAMIID:     {"ami-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:ec2:us-east-1::image/ami-0abcdef1234567890"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:ec2:us-east-1:123456789012:volume/vol-049df61146c4d7901"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldVolumeID:  {"vol-049df61146c4d7901"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:ec2:us-east-1:123456789012:volume/vol-049df61146c4d7901"))
+	// resource type and id prefix must match
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:ec2:us-east-1:123456789012:volume/snap-1234567890abcdef0"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:ec2:us-east-1:123456789012:volume/snap-1234567890abcdef0"))
+}
+
+func TestScanRegion(t *testing.T) {
+	for _, region := range []string{"us-east-1", "eu-central-1", "ap-southeast-2", "us-gov-west-1"} {
+		require.Equal(t, map[pantherlog.FieldID][]string{
+			FieldRegion: {region},
+		}, pantherlog.InspectScan(ScanRegion, region))
+	}
+	for _, input := range []string{"", "us-east", "us-east-1a", "US-EAST-1", "global", "us-east-12"} {
+		require.Nil(t, pantherlog.InspectScan(ScanRegion, input), input)
+	}
+}
+
+func TestScanImageRef(t *testing.T) {
+	const digest = "sha256:b5b2b2c507a0944348e0303114d8d93aaaa081732b86451d9bce1f432a537bc7"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldImageRef:  {"123456789012.dkr.ecr.us-west-2.amazonaws.com/panther/log-processor:v1.2.3@" + digest},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-west-2"},
+	}, pantherlog.InspectScan(ScanImageRef, "123456789012.dkr.ecr.us-west-2.amazonaws.com/panther/log-processor:v1.2.3@"+digest))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldImageRef: {"docker.io/library/nginx:1.19"},
+	}, pantherlog.InspectScan(ScanImageRef, "nginx:1.19"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldImageRef: {"docker.io/grafana/grafana"},
+	}, pantherlog.InspectScan(ScanImageRef, "grafana/grafana"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldImageRef: {"localhost:5000/app:latest"},
+	}, pantherlog.InspectScan(ScanImageRef, "localhost:5000/app:latest"))
+
+	for _, invalid := range []string{
+		"",
+		"Nginx:latest",
+		"nginx@sha256:123",
+		"nginx:bad tag",
+	} {
+		require.Nil(t, pantherlog.InspectScan(ScanImageRef, invalid), invalid)
+	}
+}
+
+func TestScanLogGroup(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldLogGroup: {"/aws/lambda/my-func"},
+	}, pantherlog.InspectScan(ScanLogGroup, "/aws/lambda/my-func"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldLogGroup: {"/aws/lambda/my-func"},
+	}, pantherlog.InspectScan(ScanLogGroup, "/aws/lambda/my-func:*"))
+	require.Nil(t, pantherlog.InspectScan(ScanLogGroup, ""))
+	require.Nil(t, pantherlog.InspectScan(ScanLogGroup, ":*"))
+	require.Nil(t, pantherlog.InspectScan(ScanLogGroup, "my group"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/my-func:*"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldLogGroup:  {"/aws/lambda/my-func"},
+	}, pantherlog.InspectScan(ScanLogGroup, "arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/my-func:*"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/my-func:log-stream:2020/01/01/[$LATEST]abc"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldLogGroup:  {"/aws/lambda/my-func"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/my-func:log-stream:2020/01/01/[$LATEST]abc"))
+	// other logs resources are ignored
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:logs:us-east-1:123456789012:destination:my-destination"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:logs:us-east-1:123456789012:destination:my-destination"))
+}
+
+func TestScanKMSKey(t *testing.T) {
+	for _, input := range []string{
+		"1234abcd-12ab-34cd-56ef-1234567890ab",
+		"mrk-1234abcd12ab34cd56ef1234567890ab",
+		"alias/my-key",
+		"alias/aws/s3",
+	} {
+		require.Equal(t, map[pantherlog.FieldID][]string{
+			FieldKMSKeyID: {input},
+		}, pantherlog.InspectScan(ScanKMSKey, input), input)
+	}
+	for _, input := range []string{
+		"",
+		"1234abcd-12ab-34cd-56ef-1234567890",
+		"1234ABCD-12AB-34CD-56EF-1234567890AB",
+		"alias/",
+		"alias/my key",
+		"my-key",
+	} {
+		require.Nil(t, pantherlog.InspectScan(ScanKMSKey, input), input)
+	}
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldKMSKeyID:  {"1234abcd-12ab-34cd-56ef-1234567890ab"},
+	}, pantherlog.InspectScan(ScanKMSKey, "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:kms:us-east-1:123456789012:alias/my-key"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldKMSKeyID:  {"alias/my-key"},
+	}, pantherlog.InspectScan(ScanARN, "arn:aws:kms:us-east-1:123456789012:alias/my-key"))
+}
+
+func TestScanKMSGrantID(t *testing.T) {
+	const grantID = "0c237476b39f8bc44e45212e08498fbe3151305030726c0590dd8d3e9f3d6a60"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldKMSGrantID: {grantID},
+	}, pantherlog.InspectScan(ScanKMSGrantID, grantID))
+	require.Nil(t, pantherlog.InspectScan(ScanKMSGrantID, "0c237476b39f8bc4"))
+	require.Nil(t, pantherlog.InspectScan(ScanKMSGrantID, "alias/aws/s3"))
+}
+
+func TestScanCloudTrailFile(t *testing.T) {
+	const digestFile = "123456789012_CloudTrail-Digest_us-east-2_management-events_us-east-2_20200102T150405Z.json.gz"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldCloudTrailFile: {digestFile},
+		FieldAccountID:      {"123456789012"},
+		FieldRegion:         {"us-east-2"},
+	}, pantherlog.InspectScan(ScanCloudTrailFile, "AWSLogs/123456789012/CloudTrail-Digest/us-east-2/2020/01/02/"+digestFile))
+	const logFile = "123456789012_CloudTrail_eu-west-1_20200102T1505Z_3f2Kq8fHx1yZ6wT4.json.gz"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldCloudTrailFile: {logFile},
+		FieldAccountID:      {"123456789012"},
+		FieldRegion:         {"eu-west-1"},
+	}, pantherlog.InspectScan(ScanCloudTrailFile, logFile))
+	require.Nil(t, pantherlog.InspectScan(ScanCloudTrailFile, "AWSLogs/123456789012/CloudTrail/us-east-2/2020/01/02/notes.txt"))
+}
+
+func TestScanARNLoadBalancer(t *testing.T) {
+	const albARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/my-alb/50dc6c495c0c9188"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:              {albARN},
+		FieldPartition:        {"aws"},
+		FieldAccountID:        {"123456789012"},
+		FieldRegion:           {"us-east-1"},
+		FieldLoadBalancerName: {"my-alb"},
+	}, pantherlog.InspectScan(ScanARN, albARN))
+	const targetGroupARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/my-targets/73e2d6bc24d8a067"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:             {targetGroupARN},
+		FieldPartition:       {"aws"},
+		FieldAccountID:       {"123456789012"},
+		FieldRegion:          {"us-east-1"},
+		FieldTargetGroupName: {"my-targets"},
+	}, pantherlog.InspectScan(ScanARN, targetGroupARN))
+	const classicARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/my-classic-elb"
+	require.Equal(t, []string{"my-classic-elb"}, pantherlog.InspectScan(ScanARN, classicARN)[FieldLoadBalancerName])
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldLoadBalancerName: {"my-alb"},
+	}, pantherlog.InspectScan(ScanLoadBalancerName, "my-alb"))
+	require.Equal(t, []string{"my-targets"}, pantherlog.InspectScan(ScanTargetGroupName, targetGroupARN)[FieldTargetGroupName])
+	require.Nil(t, pantherlog.InspectScan(ScanLoadBalancerName, "-invalid-"))
+}
+
+func TestScanAvailabilityZone(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAvailabilityZone: {"us-east-1a"},
+		FieldRegion:           {"us-east-1"},
+	}, pantherlog.InspectScan(ScanAvailabilityZone, "us-east-1a"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAvailabilityZone: {"us-gov-west-1b"},
+		FieldRegion:           {"us-gov-west-1"},
+	}, pantherlog.InspectScan(ScanAvailabilityZone, "us-gov-west-1b"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAvailabilityZone: {"us-west-2-lax-1a"},
+		FieldRegion:           {"us-west-2"},
+	}, pantherlog.InspectScan(ScanAvailabilityZone, "us-west-2-lax-1a"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAvailabilityZone: {"use1-az1"},
+	}, pantherlog.InspectScan(ScanAvailabilityZone, "use1-az1"))
+	require.Nil(t, pantherlog.InspectScan(ScanAvailabilityZone, "us-east-1"))
+	require.Nil(t, pantherlog.InspectScan(ScanAvailabilityZone, "az1"))
+}
+
+func TestScanResolverEndpointID(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldResolverEndpointID: {"rslvr-in-1a2b3c4d5e6f7g8h9"},
+	}, pantherlog.InspectScan(ScanResolverEndpointID, "rslvr-in-1a2b3c4d5e6f7g8h9"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldResolverEndpointID: {"rslvr-out-0123456789abcdef0"},
+	}, pantherlog.InspectScan(ScanResolverEndpointID, "rslvr-out-0123456789abcdef0"))
+	require.Nil(t, pantherlog.InspectScan(ScanResolverEndpointID, "rslvr-rr-1a2b3c4d5e6f7g8h9"))
+	require.Nil(t, pantherlog.InspectScan(ScanResolverEndpointID, "rslvr-in-1a2b"))
+}
+
+func TestScanDNSQueryName(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		pantherlog.FieldDomainName: {"www.example.com"},
+	}, pantherlog.InspectScan(ScanDNSQueryName, "www.example.com."))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		pantherlog.FieldDomainName: {"example.com"},
+	}, pantherlog.InspectScan(ScanDNSQueryName, "example.com"))
+	require.Nil(t, pantherlog.InspectScan(ScanDNSQueryName, "."))
+}
+
+func TestScanTag(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"Name:web-server"},
+	}, pantherlog.InspectScan(ScanTag, "Name:web-server"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"env:"},
+	}, pantherlog.InspectScan(ScanTag, "env:"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"env:prod"},
+	}, pantherlog.InspectScan(ScanTag, " env :prod"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"owner:arn:aws:iam::123456789012:user/alice"},
+	}, pantherlog.InspectScan(ScanTag, "owner:arn:aws:iam::123456789012:user/alice"))
+	require.Nil(t, pantherlog.InspectScan(ScanTag, "web-server"))
+	require.Nil(t, pantherlog.InspectScan(ScanTag, ":web-server"))
+	require.Nil(t, pantherlog.InspectScan(ScanTag, " :web-server"))
+	require.Nil(t, pantherlog.InspectScan(ScanTag, ""))
+}
+
+func TestRegisteredTagScanner(t *testing.T) {
+	scanner, fields := pantherlog.LookupScanner("aws_tag")
+	require.NotNil(t, scanner)
+	require.Equal(t, []pantherlog.FieldID{FieldTag}, fields)
+
+	values := pantherlog.ValueBuffer{}
+	scanner.ScanValues(&values, "web-server")
+	require.True(t, values.IsEmpty())
+	scanner.ScanValues(&values, "Name:web-server")
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"Name:web-server"},
+	}, values.Inspect())
+}
+
+func TestScanTagResourceName(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag:          {"Name:web-server"},
+		FieldResourceName: {"web-server"},
+	}, pantherlog.InspectScan(ScanTagResourceName, "Name:web-server"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"env:prod"},
+	}, pantherlog.InspectScan(ScanTagResourceName, "env:prod"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"Name:"},
+	}, pantherlog.InspectScan(ScanTagResourceName, "Name:"))
+}
+
+func TestScanTagIdentifiers(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag:       {"owner:arn:aws:iam::123456789012:user/alice"},
+		FieldARN:       {"arn:aws:iam::123456789012:user/alice"},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldIAMName:   {"alice"},
+	}, pantherlog.InspectScan(ScanTagIdentifiers, "owner:arn:aws:iam::123456789012:user/alice"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag:        {"parent:i-0abcdef1234567890"},
+		FieldInstanceID: {"i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanTagIdentifiers, "parent:i-0abcdef1234567890"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag:       {"billing:123456789012"},
+		FieldAccountID: {"123456789012"},
+	}, pantherlog.InspectScan(ScanTagIdentifiers, "billing:123456789012"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"env:prod"},
+	}, pantherlog.InspectScan(ScanTagIdentifiers, "env:prod"))
+	// Tag values are not split again
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"a:b:i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanTagIdentifiers, "a:b:i-0abcdef1234567890"))
+	require.Nil(t, pantherlog.InspectScan(ScanTagIdentifiers, ":arn:aws:iam::123456789012:user/alice"))
+	// Recursion is opt-in
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldTag: {"parent:i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanTag, "parent:i-0abcdef1234567890"))
+}
+
+func TestScanARNShort(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:        {"arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890"},
+		FieldPartition:  {"aws"},
+		FieldARNShort:   {"ec2:123456789012:instance/i-0abcdef1234567890"},
+		FieldAccountID:  {"123456789012"},
+		FieldRegion:     {"us-east-1"},
+		FieldInstanceID: {"i-0abcdef1234567890"},
+	}, pantherlog.InspectScan(ScanARNShort, "arn:aws:ec2:us-east-1:123456789012:instance/i-0abcdef1234567890"))
+	// IAM and S3 ARNs have no region
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:iam::123456789012:role/admin"},
+		FieldPartition: {"aws"},
+		FieldARNShort:  {"iam:123456789012:role/admin"},
+		FieldAccountID: {"123456789012"},
+		FieldIAMName:   {"admin"},
+	}, pantherlog.InspectScan(ScanARNShort, "arn:aws:iam::123456789012:role/admin"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {"arn:aws:s3:::my-bucket/key"},
+		FieldPartition: {"aws"},
+		FieldARNShort:  {"s3::my-bucket/key"},
+		FieldS3Bucket:  {"my-bucket"},
+	}, pantherlog.InspectScan(ScanARNShort, "arn:aws:s3:::my-bucket/key"))
+	// The same resource with or without a region
+	require.Equal(t,
+		pantherlog.InspectScan(ScanARNShort, "arn:aws:sns:us-east-1:123456789012:topic")[FieldARNShort],
+		pantherlog.InspectScan(ScanARNShort, "arn:aws:sns::123456789012:topic")[FieldARNShort])
+	require.Nil(t, pantherlog.InspectScan(ScanARNShort, "not-an-arn"))
+}
+
+func TestScanStackName(t *testing.T) {
+	const stackARN = "arn:aws:cloudformation:us-east-1:123456789012:stack/panther-core/1a2b3c4d-1234-5678-9abc-def012345678"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldARN:       {stackARN},
+		FieldPartition: {"aws"},
+		FieldAccountID: {"123456789012"},
+		FieldRegion:    {"us-east-1"},
+		FieldStackName: {"panther-core"},
+	}, pantherlog.InspectScan(ScanARN, stackARN))
+	require.Equal(t, pantherlog.InspectScan(ScanARN, stackARN), pantherlog.InspectScan(ScanStackName, stackARN))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldStackName: {"panther-core"},
+	}, pantherlog.InspectScan(ScanStackName, "panther-core"))
+	require.Nil(t, pantherlog.InspectScan(ScanStackName, "1-invalid"))
+	require.Nil(t, pantherlog.InspectScan(ScanStackName, "panther_core"))
+}
+
+func TestScanFindingType(t *testing.T) {
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldFindingType:     {"Recon:EC2/PortProbeUnprotectedPort"},
+		FieldFindingCategory: {"Recon"},
+	}, pantherlog.InspectScan(ScanFindingType, "Recon:EC2/PortProbeUnprotectedPort"))
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldFindingType:     {"Backdoor:EC2/C&CActivity.B!DNS"},
+		FieldFindingCategory: {"Backdoor"},
+	}, pantherlog.InspectScan(ScanFindingType, "Backdoor:EC2/C&CActivity.B!DNS"))
+	require.Nil(t, pantherlog.InspectScan(ScanFindingType, "Recon:EC2"))
+	require.Nil(t, pantherlog.InspectScan(ScanFindingType, "EC2/PortProbeUnprotectedPort"))
+	require.Nil(t, pantherlog.InspectScan(ScanFindingType, "Recon:EC2/"))
+	require.Nil(t, pantherlog.InspectScan(ScanFindingType, "Recon EC2/PortProbe"))
+}
+
+func TestScanSeverity(t *testing.T) {
+	for input, expect := range map[string]string{
+		"0":        SeverityLow,
+		"2.0":      SeverityLow,
+		"3.9":      SeverityLow,
+		"4.0":      SeverityMedium,
+		"5.0":      SeverityMedium,
+		"7":        SeverityHigh,
+		"8.0":      SeverityHigh,
+		"8.9":      SeverityHigh,
+		"9.0":      SeverityCritical,
+		"LOW":      SeverityLow,
+		"Medium":   SeverityMedium,
+		"high":     SeverityHigh,
+		" HIGH ":   SeverityHigh,
+		"CRITICAL": SeverityCritical,
+	} {
+		require.Equal(t, map[pantherlog.FieldID][]string{
+			FieldSeverity: {expect},
+		}, pantherlog.InspectScan(ScanSeverity, input), input)
+	}
+	for _, input := range []string{"", "-1", "10.1", "NaN", "INFORMATIONAL", "foo"} {
+		require.Nil(t, pantherlog.InspectScan(ScanSeverity, input), input)
+	}
+}
+
+func TestScanPrincipalID(t *testing.T) {
+	// Assumed role
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldPrincipalID: {"AROACKCEVSQ6C2EXAMPLE:session-name"},
+		FieldAccessKeyID: {"AROACKCEVSQ6C2EXAMPLE"},
+		FieldSessionName: {"session-name"},
+	}, pantherlog.InspectScan(ScanPrincipalID, "AROACKCEVSQ6C2EXAMPLE:session-name"))
+	// IAM user
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldPrincipalID: {"AIDACKCEVSQ6C2EXAMPLE"},
+		FieldAccessKeyID: {"AIDACKCEVSQ6C2EXAMPLE"},
+	}, pantherlog.InspectScan(ScanPrincipalID, "AIDACKCEVSQ6C2EXAMPLE"))
+	// Federated user
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldPrincipalID: {"123456789012:user"},
+		FieldAccountID:   {"123456789012"},
+		FieldSessionName: {"user"},
+	}, pantherlog.InspectScan(ScanPrincipalID, "123456789012:user"))
+	require.Nil(t, pantherlog.InspectScan(ScanPrincipalID, "anonymous"))
+	require.Nil(t, pantherlog.InspectScan(ScanPrincipalID, "AROA:session-name"))
+	require.Nil(t, pantherlog.InspectScan(ScanPrincipalID, ""))
+}
@@ -0,0 +1,81 @@
+// Package azurelogs has indicator scanners for Microsoft Azure resources
+package azurelogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
+)
+
+// Azure indicator fields
+// We start at an offset so that ids do not collide with the indicator fields defined in pantherlog, awslogs or gcplogs.
+const (
+	FieldAzureSubscription pantherlog.FieldID = 300 + iota
+	FieldAzureResourceGroup
+	FieldAzureResourceID
+)
+
+func init() {
+	pantherlog.MustRegisterIndicator(FieldAzureSubscription, pantherlog.FieldMeta{
+		Name:        "PantherAnyAzureSubscriptions",
+		NameJSON:    "p_any_azure_subscriptions",
+		Description: "Panther added field with collection of azure subscription ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldAzureResourceGroup, pantherlog.FieldMeta{
+		Name:        "PantherAnyAzureResourceGroups",
+		NameJSON:    "p_any_azure_resource_groups",
+		Description: "Panther added field with collection of azure resource group names associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldAzureResourceID, pantherlog.FieldMeta{
+		Name:        "PantherAnyAzureResourceIDs",
+		NameJSON:    "p_any_azure_resource_ids",
+		Description: "Panther added field with collection of azure resource ids associated with the row",
+	})
+	pantherlog.MustRegisterScanner("azure_resource_id", pantherlog.ValueScannerFunc(ScanAzureResourceID),
+		FieldAzureResourceID, FieldAzureSubscription, FieldAzureResourceGroup)
+}
+
+// Subscription ids are GUIDs
+var azureSubscriptionRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ScanAzureResourceID scans an Azure resource id for the resource id, subscription id and resource group name.
+// Resource ids have the form `/subscriptions/<subscription-id>/resourceGroups/<resource-group>/providers/...`.
+// Segment names are matched case-insensitively as Azure does not normalize their casing in logs.
+// See https://docs.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-resource#resourceid
+func ScanAzureResourceID(w pantherlog.ValueWriter, input string) {
+	if !strings.HasPrefix(input, "/") {
+		return
+	}
+	segments := strings.Split(input[1:], "/")
+	if len(segments) < 2 || !strings.EqualFold(segments[0], "subscriptions") {
+		return
+	}
+	subscription := segments[1]
+	if !azureSubscriptionRegex.MatchString(subscription) {
+		return
+	}
+	w.WriteValues(FieldAzureResourceID, input)
+	w.WriteValues(FieldAzureSubscription, subscription)
+	if len(segments) >= 4 && strings.EqualFold(segments[2], "resourceGroups") && segments[3] != "" {
+		w.WriteValues(FieldAzureResourceGroup, segments[3])
+	}
+}
@@ -0,0 +1,45 @@
+package azurelogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
+)
+
+// helper to collect the values written by a scanner
+func TestScanAzureResourceID(t *testing.T) {
+	const id = "/subscriptions/00000000-1111-2222-3333-444444444444/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAzureResourceID:    {id},
+		FieldAzureSubscription:  {"00000000-1111-2222-3333-444444444444"},
+		FieldAzureResourceGroup: {"my-rg"},
+	}, pantherlog.InspectScan(ScanAzureResourceID, id))
+	const subscriptionID = "/SUBSCRIPTIONS/00000000-1111-2222-3333-444444444444"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldAzureResourceID:   {subscriptionID},
+		FieldAzureSubscription: {"00000000-1111-2222-3333-444444444444"},
+	}, pantherlog.InspectScan(ScanAzureResourceID, subscriptionID))
+	require.Nil(t, pantherlog.InspectScan(ScanAzureResourceID, "/subscriptions/not-a-guid/resourceGroups/my-rg"))
+	require.Nil(t, pantherlog.InspectScan(ScanAzureResourceID, "subscriptions/00000000-1111-2222-3333-444444444444"))
+	require.Nil(t, pantherlog.InspectScan(ScanAzureResourceID, "/tenants/00000000-1111-2222-3333-444444444444"))
+}
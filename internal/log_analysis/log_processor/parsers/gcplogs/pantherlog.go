@@ -0,0 +1,85 @@
+package gcplogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
+)
+
+// GCP indicator fields
+// We start at an offset so that ids do not collide with the indicator fields defined in pantherlog or awslogs.
+const (
+	FieldGCPProject pantherlog.FieldID = 200 + iota
+	FieldGCPResourceName
+)
+
+func init() {
+	pantherlog.MustRegisterIndicator(FieldGCPProject, pantherlog.FieldMeta{
+		Name:        "PantherAnyGCPProjects",
+		NameJSON:    "p_any_gcp_projects",
+		Description: "Panther added field with collection of gcp project ids associated with the row",
+	})
+	pantherlog.MustRegisterIndicator(FieldGCPResourceName, pantherlog.FieldMeta{
+		Name:        "PantherAnyGCPResourceNames",
+		NameJSON:    "p_any_gcp_resource_names",
+		Description: "Panther added field with collection of gcp resource names associated with the row",
+	})
+	pantherlog.MustRegisterScanner("gcp_resource", pantherlog.ValueScannerFunc(ScanGCPResource),
+		FieldGCPResourceName, FieldGCPProject)
+}
+
+// Project ids are 6 to 30 lowercase letters, digits or hyphens starting with a letter.
+// Resource names can also refer to a project by its numeric project number.
+// See https://cloud.google.com/resource-manager/docs/creating-managing-projects
+var gcpProjectRegex = regexp.MustCompile(`^(?:[a-z][a-z0-9-]{4,28}[a-z0-9]|\d+)$`)
+
+// ScanGCPResource scans a GCP resource name (ie `projects/my-project/zones/us-central1-a/instances/my-instance`)
+// for the resource name and the project id.
+// Full resource names prefixed with the service name (ie `//compute.googleapis.com/projects/...`) are also accepted.
+// See https://cloud.google.com/apis/design/resource_names
+func ScanGCPResource(w pantherlog.ValueWriter, input string) {
+	name := input
+	if strings.HasPrefix(name, "//") {
+		// Strip the service name of a full resource name
+		pos := strings.IndexByte(name[2:], '/')
+		if pos == -1 {
+			return
+		}
+		name = name[2+pos+1:]
+	}
+	// Resource names are a sequence of collection id and resource id pairs
+	segments := strings.Split(name, "/")
+	if len(segments)%2 != 0 || segments[0] != "projects" {
+		return
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return
+		}
+	}
+	project := segments[1]
+	if !gcpProjectRegex.MatchString(project) {
+		return
+	}
+	w.WriteValues(FieldGCPResourceName, input)
+	w.WriteValues(FieldGCPProject, project)
+}
@@ -0,0 +1,45 @@
+package gcplogs
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/internal/log_analysis/log_processor/pantherlog"
+)
+
+// helper to collect the values written by a scanner
+func TestScanGCPResource(t *testing.T) {
+	const name = "projects/my-project-1/zones/us-central1-a/instances/my-instance"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldGCPResourceName: {name},
+		FieldGCPProject:      {"my-project-1"},
+	}, pantherlog.InspectScan(ScanGCPResource, name))
+	const fullName = "//compute.googleapis.com/projects/123456789/zones/us-central1-a/instances/my-instance"
+	require.Equal(t, map[pantherlog.FieldID][]string{
+		FieldGCPResourceName: {fullName},
+		FieldGCPProject:      {"123456789"},
+	}, pantherlog.InspectScan(ScanGCPResource, fullName))
+	require.Nil(t, pantherlog.InspectScan(ScanGCPResource, "projects/my-project-1/zones"))
+	require.Nil(t, pantherlog.InspectScan(ScanGCPResource, "projects/My_Project/zones/us-central1-a"))
+	require.Nil(t, pantherlog.InspectScan(ScanGCPResource, "folders/123/projects/my-project-1"))
+	require.Nil(t, pantherlog.InspectScan(ScanGCPResource, "projects//zones/us-central1-a"))
+}
@@ -62,6 +62,7 @@ type PantherLog struct {
 	PantherAnySHA1Hashes   *PantherAnyString `json:"p_any_sha1_hashes,omitempty" description:"Panther added field with collection of SHA1 hashes associated with the row"`
 	PantherAnyMD5Hashes    *PantherAnyString `json:"p_any_md5_hashes,omitempty" description:"Panther added field with collection of MD5 hashes associated with the row"`
 	PantherAnySHA256Hashes *PantherAnyString `json:"p_any_sha256_hashes,omitempty" description:"Panther added field with collection of SHA256 hashes of any algorithm associated with the row"`
+	PantherAnyEmails       *PantherAnyString `json:"p_any_emails,omitempty" description:"Panther added field with collection of email addresses associated with the row"`
 }
 
 type PantherAnyString struct { // needed to declare as struct (rather than map) for CF generation
@@ -243,6 +244,28 @@ func (pl *PantherLog) AppendAnySHA256HashesPtr(values ...*string) {
 	}
 }
 
+// AppendAnyEmails appends valid email addresses, normalized to lowercase
+func (pl *PantherLog) AppendAnyEmails(values ...string) {
+	for _, value := range values {
+		email, ok := pantherlog.NormalizeEmail(value)
+		if !ok {
+			continue
+		}
+		if pl.PantherAnyEmails == nil { // lazy create
+			pl.PantherAnyEmails = NewPantherAnyString()
+		}
+		AppendAnyString(pl.PantherAnyEmails, email)
+	}
+}
+
+func (pl *PantherLog) AppendAnyEmailPtrs(values ...*string) {
+	for _, value := range values {
+		if value != nil {
+			pl.AppendAnyEmails(*value)
+		}
+	}
+}
+
 func AppendAnyString(any *PantherAnyString, values ...string) {
 	// add new if not present
 	for _, v := range values {
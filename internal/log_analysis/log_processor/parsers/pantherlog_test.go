@@ -249,3 +249,17 @@ func TestAppendAnyMD5Hashes(t *testing.T) {
 	event.AppendAnyMD5HashPtrs(&value)
 	require.Equal(t, expectedAny, event.PantherAnyMD5Hashes)
 }
+
+func TestAppendAnyEmails(t *testing.T) {
+	event := PantherLog{}
+	event.AppendAnyEmails("Jane.Doe@Example.com", "not-an-email", "")
+	event.AppendAnyEmailPtrs(aws.String("jane.doe@example.com"), aws.String("ops+alerts@example.org"), nil)
+
+	expectedAny := &PantherAnyString{
+		set: map[string]struct{}{
+			"jane.doe@example.com":   {},
+			"ops+alerts@example.org": {},
+		},
+	}
+	require.Equal(t, expectedAny, event.PantherAnyEmails)
+}
@@ -20,7 +20,6 @@ package awscfn
  */
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -61,11 +60,47 @@ func WaitForStackDelete(client *cloudformation.CloudFormation, logger *zap.Sugar
 		cloudformation.StackStatusDeleteInProgress)
 }
 
+// WaitForStackDeleteBackoff is like WaitForStackDelete, but the time between polls is given by nextInterval.
+//
+// Use ExponentialBackoff to poll frequently at first and then slow down for stacks which take a long time to delete.
+func WaitForStackDeleteBackoff(client *cloudformation.CloudFormation, logger *zap.SugaredLogger, stackName string,
+	nextInterval func() time.Duration) (*cloudformation.Stack, error) {
+
+	return waitForStack(client, logger, stackName, cloudformation.StackStatusDeleteComplete, nextInterval,
+		cloudformation.StackStatusDeleteInProgress)
+}
+
+// ExponentialBackoff returns a function which yields the poll interval to wait next.
+//
+// The first interval is initial, and each following interval is twice the previous one, up to max.
+func ExponentialBackoff(initial, max time.Duration) func() time.Duration {
+	next := initial
+	return func() time.Duration {
+		interval := next
+		if interval > max {
+			interval = max
+		}
+		next = 2 * interval
+		return interval
+	}
+}
+
+// StackStatusError is returned when a stack stops in a terminal status other than the one we waited for.
+type StackStatusError struct {
+	StackName string
+	Status    string
+}
+
+// Error returns the stack status, e.g. "DELETE_FAILED"
+func (e *StackStatusError) Error() string {
+	return e.Status
+}
+
 // Wait for the stack to reach a terminal status and then return its details.
 //
 // 1) Keep waiting while stack status is inProgress
 // 2) If stack status is successStatus, return stack details
-// 3) If stack status is neither success nor inProgress, log failing resources and return an error
+// 3) If stack status is neither success nor inProgress, log failing resources and return a *StackStatusError
 //
 // This allows us to report errors to the user immediately, e.g. an "UPDATE_ROLLBACK_IN_PROGRESS"
 // is considered a failed update - we don't have to wait until the stack is finished before finding
@@ -77,6 +112,13 @@ func WaitForStackDelete(client *cloudformation.CloudFormation, logger *zap.Sugar
 func WaitForStack(client *cloudformation.CloudFormation, logger *zap.SugaredLogger, stackName, successStatus string,
 	pollInterval time.Duration, inProgress ...string) (*cloudformation.Stack, error) {
 
+	nextInterval := func() time.Duration { return pollInterval }
+	return waitForStack(client, logger, stackName, successStatus, nextInterval, inProgress...)
+}
+
+func waitForStack(client *cloudformation.CloudFormation, logger *zap.SugaredLogger, stackName, successStatus string,
+	nextInterval func() time.Duration, inProgress ...string) (*cloudformation.Stack, error) {
+
 	// See all stack status codes and exactly what they mean here:
 	// https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/using-cfn-describing-stacks.html
 
@@ -146,7 +188,7 @@ func WaitForStack(client *cloudformation.CloudFormation, logger *zap.SugaredLogg
 			lastUserMessage = time.Now()
 		}
 
-		time.Sleep(pollInterval)
+		time.Sleep(nextInterval())
 	}
 
 	// Done waiting
@@ -156,7 +198,7 @@ func WaitForStack(client *cloudformation.CloudFormation, logger *zap.SugaredLogg
 
 	// Error - stack entered an invalid state
 	LogResourceFailures(client, logger, &stackName, start)
-	return nil, errors.New(*stack.StackStatus)
+	return nil, &StackStatusError{StackName: stackName, Status: *stack.StackStatus}
 }
 
 // Returns true if the given error is from describing a stack that doesn't exist.
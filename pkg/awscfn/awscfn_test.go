@@ -0,0 +1,56 @@
+package awscfn
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	nextInterval := ExponentialBackoff(5*time.Second, time.Minute)
+
+	// Mock clock which advances by the poll interval after every poll
+	var clock time.Duration
+	var intervals []time.Duration
+	for clock < 5*time.Minute {
+		interval := nextInterval()
+		intervals = append(intervals, interval)
+		clock += interval
+	}
+
+	assert.Equal(t, []time.Duration{
+		5 * time.Second,
+		10 * time.Second,
+		20 * time.Second,
+		40 * time.Second,
+		time.Minute,
+		time.Minute,
+		time.Minute,
+		time.Minute,
+	}, intervals)
+}
+
+func TestExponentialBackoffInitialAboveMax(t *testing.T) {
+	nextInterval := ExponentialBackoff(time.Minute, 10*time.Second)
+	assert.Equal(t, 10*time.Second, nextInterval())
+	assert.Equal(t, 10*time.Second, nextInterval())
+}
@@ -23,16 +23,24 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/athena"
 	"github.com/aws/aws-sdk-go/service/athena/athenaiface"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
 	"github.com/aws/aws-sdk-go/service/eventbridge"
 	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
 	"github.com/aws/aws-sdk-go/service/firehose"
 	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
 	"github.com/aws/aws-sdk-go/service/glue"
 	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -41,6 +49,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -88,6 +98,54 @@ func (m *S3Mock) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListOb
 	return args.Error(1)
 }
 
+func (m *S3Mock) ListBuckets(input *s3.ListBucketsInput) (*s3.ListBucketsOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.ListBucketsOutput), args.Error(1)
+}
+
+func (m *S3Mock) GetBucketTagging(input *s3.GetBucketTaggingInput) (*s3.GetBucketTaggingOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.GetBucketTaggingOutput), args.Error(1)
+}
+
+func (m *S3Mock) ListObjectVersionsPages(input *s3.ListObjectVersionsInput,
+	f func(page *s3.ListObjectVersionsOutput, lastPage bool) bool) error {
+
+	args := m.Called(input, f)
+	f(args.Get(0).(*s3.ListObjectVersionsOutput), true)
+	return args.Error(1)
+}
+
+func (m *S3Mock) ListObjectVersions(input *s3.ListObjectVersionsInput) (*s3.ListObjectVersionsOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.ListObjectVersionsOutput), args.Error(1)
+}
+
+func (m *S3Mock) PutBucketAcl(input *s3.PutBucketAclInput) (*s3.PutBucketAclOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.PutBucketAclOutput), args.Error(1)
+}
+
+func (m *S3Mock) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.DeleteObjectsOutput), args.Error(1)
+}
+
+func (m *S3Mock) DeleteBucket(input *s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.DeleteBucketOutput), args.Error(1)
+}
+
+func (m *S3Mock) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.PutObjectOutput), args.Error(1)
+}
+
+func (m *S3Mock) GetBucketRequestPayment(input *s3.GetBucketRequestPaymentInput) (*s3.GetBucketRequestPaymentOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*s3.GetBucketRequestPaymentOutput), args.Error(1)
+}
+
 type LambdaMock struct {
 	lambdaiface.LambdaAPI
 	mock.Mock
@@ -227,6 +285,17 @@ type GlueMock struct {
 	mock.Mock
 }
 
+func (m *GlueMock) GetDatabasesPages(input *glue.GetDatabasesInput, f func(*glue.GetDatabasesOutput, bool) bool) error {
+	args := m.Called(input, f)
+	f(args.Get(0).(*glue.GetDatabasesOutput), true)
+	return args.Error(1)
+}
+
+func (m *GlueMock) DeleteDatabase(input *glue.DeleteDatabaseInput) (*glue.DeleteDatabaseOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*glue.DeleteDatabaseOutput), args.Error(1)
+}
+
 func (m *GlueMock) CreateTable(input *glue.CreateTableInput) (*glue.CreateTableOutput, error) {
 	args := m.Called(input)
 	return args.Get(0).(*glue.CreateTableOutput), args.Error(1)
@@ -305,3 +374,156 @@ func (m *FirehoseMock) PutRecordBatchWithContext(
 	args := m.Called(ctx, input, options)
 	return args.Get(0).(*firehose.PutRecordBatchOutput), args.Error(1)
 }
+
+type StsMock struct {
+	stsiface.STSAPI
+	mock.Mock
+}
+
+func (m *StsMock) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*sts.AssumeRoleOutput), args.Error(1)
+}
+
+func (m *StsMock) AssumeRoleWithContext(ctx aws.Context, input *sts.AssumeRoleInput, options ...request.Option) (*sts.AssumeRoleOutput, error) {
+	args := m.Called(ctx, input, options)
+	return args.Get(0).(*sts.AssumeRoleOutput), args.Error(1)
+}
+
+func (m *StsMock) GetCallerIdentity(input *sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*sts.GetCallerIdentityOutput), args.Error(1)
+}
+
+type CloudFormationMock struct {
+	cloudformationiface.CloudFormationAPI
+	mock.Mock
+}
+
+func (m *CloudFormationMock) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*cloudformation.DescribeStacksOutput), args.Error(1)
+}
+
+func (m *CloudFormationMock) DeleteStack(input *cloudformation.DeleteStackInput) (*cloudformation.DeleteStackOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*cloudformation.DeleteStackOutput), args.Error(1)
+}
+
+func (m *CloudFormationMock) DescribeStackEventsPages(input *cloudformation.DescribeStackEventsInput,
+	f func(*cloudformation.DescribeStackEventsOutput, bool) bool) error {
+
+	args := m.Called(input, f)
+	if err := args.Error(1); err != nil {
+		return err
+	}
+	f(args.Get(0).(*cloudformation.DescribeStackEventsOutput), true)
+	return nil
+}
+
+func (m *CloudFormationMock) ListExportsPages(input *cloudformation.ListExportsInput,
+	f func(*cloudformation.ListExportsOutput, bool) bool) error {
+
+	args := m.Called(input, f)
+	if err := args.Error(1); err != nil {
+		return err
+	}
+	f(args.Get(0).(*cloudformation.ListExportsOutput), true)
+	return nil
+}
+
+func (m *CloudFormationMock) ListImportsPages(input *cloudformation.ListImportsInput,
+	f func(*cloudformation.ListImportsOutput, bool) bool) error {
+
+	args := m.Called(input, f)
+	if err := args.Error(1); err != nil {
+		return err
+	}
+	f(args.Get(0).(*cloudformation.ListImportsOutput), true)
+	return nil
+}
+
+type EcrMock struct {
+	ecriface.ECRAPI
+	mock.Mock
+}
+
+func (m *EcrMock) DescribeRepositoriesPages(input *ecr.DescribeRepositoriesInput,
+	f func(*ecr.DescribeRepositoriesOutput, bool) bool) error {
+
+	args := m.Called(input, f)
+	f(args.Get(0).(*ecr.DescribeRepositoriesOutput), true)
+	return args.Error(1)
+}
+
+func (m *EcrMock) DeleteRepository(input *ecr.DeleteRepositoryInput) (*ecr.DeleteRepositoryOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ecr.DeleteRepositoryOutput), args.Error(1)
+}
+
+type IamMock struct {
+	iamiface.IAMAPI
+	mock.Mock
+}
+
+func (m *IamMock) ListRolesPages(input *iam.ListRolesInput, f func(*iam.ListRolesOutput, bool) bool) error {
+	args := m.Called(input, f)
+	f(args.Get(0).(*iam.ListRolesOutput), true)
+	return args.Error(1)
+}
+
+func (m *IamMock) ListInstanceProfilesForRolePages(input *iam.ListInstanceProfilesForRoleInput,
+	f func(*iam.ListInstanceProfilesForRoleOutput, bool) bool) error {
+
+	args := m.Called(input, f)
+	f(args.Get(0).(*iam.ListInstanceProfilesForRoleOutput), true)
+	return args.Error(1)
+}
+
+func (m *IamMock) RemoveRoleFromInstanceProfile(
+	input *iam.RemoveRoleFromInstanceProfileInput) (*iam.RemoveRoleFromInstanceProfileOutput, error) {
+
+	args := m.Called(input)
+	return args.Get(0).(*iam.RemoveRoleFromInstanceProfileOutput), args.Error(1)
+}
+
+func (m *IamMock) ListAttachedRolePoliciesPages(input *iam.ListAttachedRolePoliciesInput,
+	f func(*iam.ListAttachedRolePoliciesOutput, bool) bool) error {
+
+	args := m.Called(input, f)
+	f(args.Get(0).(*iam.ListAttachedRolePoliciesOutput), true)
+	return args.Error(1)
+}
+
+func (m *IamMock) DetachRolePolicy(input *iam.DetachRolePolicyInput) (*iam.DetachRolePolicyOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*iam.DetachRolePolicyOutput), args.Error(1)
+}
+
+func (m *IamMock) ListRolePoliciesPages(input *iam.ListRolePoliciesInput, f func(*iam.ListRolePoliciesOutput, bool) bool) error {
+	args := m.Called(input, f)
+	f(args.Get(0).(*iam.ListRolePoliciesOutput), true)
+	return args.Error(1)
+}
+
+func (m *IamMock) DeleteRolePolicy(input *iam.DeleteRolePolicyInput) (*iam.DeleteRolePolicyOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*iam.DeleteRolePolicyOutput), args.Error(1)
+}
+
+func (m *IamMock) DeleteRole(input *iam.DeleteRoleInput) (*iam.DeleteRoleOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*iam.DeleteRoleOutput), args.Error(1)
+}
+
+type OrganizationsMock struct {
+	organizationsiface.OrganizationsAPI
+	mock.Mock
+}
+
+func (m *OrganizationsMock) DescribeOrganization(
+	input *organizations.DescribeOrganizationInput) (*organizations.DescribeOrganizationOutput, error) {
+
+	args := m.Called(input)
+	return args.Get(0).(*organizations.DescribeOrganizationOutput), args.Error(1)
+}
@@ -518,7 +518,7 @@ func deployOnboardStack(settings *config.PantherConfig, outputs map[string]strin
 		})
 	} else {
 		// Delete the onboard stack if OnboardSelf was toggled off
-		err = deleteStack(cloudformation.New(awsSession), aws.String(cfnstacks.Onboard))
+		_, err = deleteStack(cloudformation.New(awsSession), aws.String(cfnstacks.Onboard), defaultMaxPollInterval, 0)
 	}
 
 	return err
@@ -174,7 +174,9 @@ func prepareStack(stackName string) (map[string]string, error) {
 			// Otherwise, there may be orphaned S3 buckets that will never be used.
 			logger.Warnf("The very first %s stack never created successfully (%s)", cfnstacks.Bootstrap, status)
 			logger.Warnf("Running 'mage teardown' to fully remove orphaned resources before trying again")
-			Teardown()
+			if err := Teardown(); err != nil {
+				return nil, err
+			}
 			return nil, nil
 		}
 
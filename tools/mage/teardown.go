@@ -19,15 +19,31 @@ package mage
  */
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/magefile/mage/mg"
 
 	"github.com/panther-labs/panther/pkg/awsbatch/s3batch"
 	"github.com/panther-labs/panther/pkg/awscfn"
@@ -38,28 +54,247 @@ import (
 const (
 	// Upper bound on the number of s3 object versions we'll delete manually.
 	s3MaxDeletes = 10000
+
+	// Timeout for posting the teardown summary to NOTIFY_WEBHOOK
+	webhookTimeout = 10 * time.Second
+
+	// Default upper bound on the time between stack status polls, override with TEARDOWN_MAX_POLL_INTERVAL
+	defaultMaxPollInterval = time.Minute
+
+	// How many times we try to delete an emptied bucket, and how long we wait before the first retry.
+	// S3 is eventually consistent, so a bucket may still look non-empty right after its objects were deleted.
+	deleteBucketAttempts = 5
+	deleteBucketBackoff  = time.Second
+
+	// How many times a stack in DELETE_FAILED is deleted again retaining the failed resources (FORCE_DESTROY)
+	forceDestroyRetries = 3
 )
 
+// Exit codes when teardown partially fails, combined as bit flags (6 means both stacks and buckets failed).
+const (
+	teardownStacksFailed  = 2
+	teardownBucketsFailed = 4
+	teardownExtrasFailed  = 8
+)
+
+// The main stacks deployed with 'mage deploy', which can be deleted in parallel.
+var parallelStacks = []string{
+	cfnstacks.Appsync,
+	cfnstacks.Cloudsec,
+	cfnstacks.Core,
+	cfnstacks.Dashboard,
+	cfnstacks.Frontend,
+	cfnstacks.LogAnalysis,
+	cfnstacks.Onboard,
+}
+
+// Returns the names of the top-level Panther stacks.
+func pantherStackNames(masterStack string) []string {
+	if masterStack != "" {
+		return []string{masterStack}
+	}
+	// bootstrap-gateway must be deleted before bootstrap
+	return append(append([]string{}, parallelStacks...), cfnstacks.Gateway, cfnstacks.Bootstrap)
+}
+
 type deleteStackResult struct {
 	stackName string
+	stackID   string
 	err       error
 }
 
+// Summary of the teardown steps, used to compute the exit code and for the NOTIFY_WEBHOOK payload.
+type teardownResult struct {
+	Account         string   `json:"account"`
+	Region          string   `json:"region"`
+	StacksDeleted   []string `json:"stacksDeleted"`
+	StacksFailed    []string `json:"stacksFailed"`
+	BucketsHandled  []string `json:"bucketsHandled"`
+	BucketsFailed   []string `json:"bucketsFailed"`
+	BucketsManual   []string `json:"bucketsManual"`
+	BucketsRetained []string `json:"bucketsRetained"`
+	BucketsSkipped  bool     `json:"bucketsSkipped"`
+	ExtrasDeleted   []string `json:"extrasDeleted"`
+	ExtrasFailed    []string `json:"extrasFailed"`
+	Duration        string   `json:"duration"`
+	ExitCode        int      `json:"exitCode"`
+
+	// Audit trail of the deleted resources, see AUDIT_UPLOAD_BUCKET
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+	Audit      []auditRecord `json:"audit"`
+
+	stacksErr  error
+	bucketsErr error
+	extrasErr  error
+}
+
+// Record the outcome of deleting a single stack.
+//
+// The stack id is used in the audit record if known, since stack names can be reused.
+func (r *teardownResult) addStack(stackName, stackID string, err error) {
+	if err != nil {
+		r.StacksFailed = append(r.StacksFailed, stackName)
+		return
+	}
+	r.StacksDeleted = append(r.StacksDeleted, stackName)
+	if stackID == "" {
+		stackID = stackName
+	}
+	r.audit(auditRecord{Type: auditTypeStack, ID: stackID, Action: auditActionDeleted})
+}
+
+// Record the outcome of emptying/deleting a single bucket with the given number of object versions.
+func (r *teardownResult) addBucket(bucketName string, objectVersions int, emptyOnly bool, err error) {
+	if _, ok := err.(*manualBucketError); ok {
+		r.BucketsManual = append(r.BucketsManual, bucketName)
+		return
+	}
+	if err != nil {
+		r.BucketsFailed = append(r.BucketsFailed, bucketName)
+		return
+	}
+	r.BucketsHandled = append(r.BucketsHandled, bucketName)
+	action := auditActionDeleted
+	switch {
+	case useExpirationPolicy(objectVersions):
+		action = auditActionExpiring
+	case emptyOnly:
+		action = auditActionEmptied
+	}
+	r.audit(auditRecord{Type: auditTypeBucket, ID: bucketName, Action: action, Objects: objectVersions})
+}
+
+// Record the outcome of deleting a single extra resource.
+func (r *teardownResult) addExtra(kind, name string, err error) {
+	if err != nil {
+		r.ExtrasFailed = append(r.ExtrasFailed, kind+"/"+name)
+		return
+	}
+	r.ExtrasDeleted = append(r.ExtrasDeleted, kind+"/"+name)
+	r.audit(auditRecord{Type: kind, ID: name, Action: auditActionDeleted})
+}
+
+// Returns 0 if teardown succeeded, otherwise the combination of the failure exit codes.
+func (r *teardownResult) exitCode() int {
+	code := 0
+	if r.stacksErr != nil {
+		code |= teardownStacksFailed
+	}
+	if r.bucketsErr != nil {
+		code |= teardownBucketsFailed
+	}
+	if r.extrasErr != nil {
+		code |= teardownExtrasFailed
+	}
+	return code
+}
+
 // Teardown Destroy all Panther infrastructure
-func Teardown() {
+func Teardown() error {
 	getSession()
-	masterStack := teardownConfirmation()
-	if err := destroyCfnStacks(masterStack); err != nil {
+	if roleARN := os.Getenv("TEARDOWN_ROLE_ARN"); roleARN != "" {
+		logger.Infof("assuming role %s", roleARN)
+		awsSession = assumeRoleSession(awsSession, sts.New(awsSession), roleARN)
+	}
+
+	// Fail fast if the credentials are not valid, before we prompt for confirmation
+	identity, err := getCallerIdentity(sts.New(awsSession))
+	if err != nil {
 		logger.Fatal(err)
 	}
+	logger.Infof("running teardown as %s", aws.StringValue(identity.Arn))
 
-	// CloudFormation will not delete any Panther S3 buckets (DeletionPolicy: Retain), we do so here.
-	destroyPantherBuckets()
+	// Fail fast on an invalid TEARDOWN_CONFIG as well
+	plan, err := teardownPlanFromEnv()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	masterStack := teardownConfirmation(identity)
+	plan.log(masterStack)
+	maxPollInterval := teardownMaxPollInterval()
+	concurrency := teardownConcurrency()
+	var retainRetries int
+	if os.Getenv("FORCE_DESTROY") != "" {
+		logger.Warn("FORCE_DESTROY is set, resources which fail to delete will be retained and orphaned")
+		retainRetries = forceDestroyRetries
+	}
+	start := time.Now()
+	result := teardownResult{
+		Account:   aws.StringValue(identity.Account),
+		Region:    *awsSession.Config.Region,
+		StartedAt: start.UTC(),
+	}
+	result.stacksErr = destroyCfnStacks(masterStack, plan.stacks, maxPollInterval, concurrency, retainRetries, &result)
+	if result.stacksErr != nil {
+		logger.Error(result.stacksErr)
+	}
+	destroyLeftovers(newInventoryClients(), masterStack, plan, &result)
 
+	result.FinishedAt = time.Now().UTC()
+	result.Duration = result.FinishedAt.Sub(start).Round(time.Second).String()
+	result.ExitCode = result.exitCode()
+	writeAuditRecord(&result)
+	if url := os.Getenv("NOTIFY_WEBHOOK"); url != "" {
+		// A failed notification is not a failed teardown
+		if err := notifyWebhook(&http.Client{Timeout: webhookTimeout}, url, &result); err != nil {
+			logger.Warnf("failed to notify webhook: %v", err)
+		}
+	}
+
+	if code := result.ExitCode; code != 0 {
+		return mg.Fatalf(code, "teardown failed (exit code %d)", code)
+	}
 	logger.Info("successfully removed Panther infrastructure")
+	return nil
 }
 
-func teardownConfirmation() string {
+// Delete the Panther buckets and the selected leftover resources after the stacks.
+//
+// Nothing is deleted if a stack failed to delete, or if STACKS excludes the bootstrap stack.
+func destroyLeftovers(clients *inventoryClients, masterStack string, plan *teardownPlan, result *teardownResult) {
+	if result.stacksErr != nil {
+		// The stacks that failed to delete may still reference the buckets, leave them alone
+		logger.Warn("skipping S3 bucket deletion since not all stacks were deleted")
+		result.BucketsSkipped = true
+		return
+	}
+	if plan.stacksOnly {
+		// The stacks which are kept may still reference the buckets, leave them alone
+		logger.Warnf("skipping S3 bucket and leftover resource deletion since %s was not deleted", cfnstacks.Bootstrap)
+		result.BucketsSkipped = true
+		return
+	}
+
+	// CloudFormation will not delete any Panther S3 buckets (DeletionPolicy: Retain), we do so here.
+	//
+	// With EMPTY_ONLY, the buckets are emptied but left in place so their names can be reused right away.
+	// With RETAIN_BUCKETS, the buckets are not touched at all (i.e. a data lake bucket shared with other teams).
+	if os.Getenv("RETAIN_BUCKETS") != "" {
+		logger.Info("RETAIN_BUCKETS is set, S3 buckets will be left intact")
+		retainPantherBuckets(clients.s3, masterStack, plan.buckets, result)
+	} else {
+		emptyOnly := os.Getenv("EMPTY_ONLY") != ""
+		if emptyOnly {
+			logger.Info("EMPTY_ONLY is set, S3 buckets will be emptied but not deleted")
+		}
+		result.bucketsErr = destroyPantherBuckets(clients.s3, masterStack, plan.buckets, emptyOnly, result)
+		if result.bucketsErr != nil {
+			logger.Error(result.bucketsErr)
+		}
+	}
+
+	// Leftover resources are only deleted if selected in TEARDOWN_CONFIG
+	if len(plan.extras) > 0 {
+		result.extrasErr = destroyExtras(clients, masterStack, plan.extras, result)
+		if result.extrasErr != nil {
+			logger.Error(result.extrasErr)
+		}
+	}
+}
+
+func teardownConfirmation(identity *sts.GetCallerIdentityOutput) string {
 	// When deploying from source ('mage deploy'), there will be several top-level stacks.
 	// When deploying the master template, there is only one main stack whose name we do not know.
 	stack := os.Getenv("STACK")
@@ -68,8 +303,21 @@ func teardownConfirmation() string {
 			cfnstacks.NumStacks)
 	}
 
-	template := "Teardown will destroy all Panther infra in account %s (%s)"
-	args := []interface{}{getAccountID(), *awsSession.Config.Region}
+	if err := verifyAccount(identity, os.Getenv("TEARDOWN_EXPECTED_ACCOUNT")); err != nil {
+		logger.Fatal(err)
+	}
+
+	// Safety rail: tearing down Panther in the organization management account is almost certainly a mistake
+	if os.Getenv("I_KNOW_WHAT_IM_DOING") == "" {
+		confirmManagementAccount(organizations.New(awsSession), aws.StringValue(identity.Account))
+	}
+
+	template := "Teardown will destroy all Panther infra in account %s (%s) as %s"
+	args := []interface{}{aws.StringValue(identity.Account), *awsSession.Config.Region, aws.StringValue(identity.Arn)}
+	if roleARN := os.Getenv("TEARDOWN_ROLE_ARN"); roleARN != "" {
+		template += " using role %s"
+		args = append(args, roleARN)
+	}
 	if stack != "" {
 		template += " with master stack '%s'"
 		args = append(args, stack)
@@ -81,65 +329,205 @@ func teardownConfirmation() string {
 		logger.Fatal("teardown aborted")
 	}
 
+	// Last chance to stop a reflexive confirmation
+	if value := os.Getenv("CONFIRM_DELAY"); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Fatalf("invalid CONFIRM_DELAY %q: %v", value, err)
+		}
+		waitConfirmDelay(delay)
+	}
+
 	return stack
 }
 
+// Count down before teardown starts, exiting if the user presses Ctrl-C.
+func waitConfirmDelay(delay time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := countdown(ctx, delay, time.Second); err != nil {
+		logger.Fatal("teardown aborted")
+	}
+}
+
+// Log the remaining time every tick until the delay elapses (nil) or the context is canceled (ctx.Err()).
+func countdown(ctx context.Context, delay, tick time.Duration) error {
+	for remaining := delay; remaining > 0; remaining -= tick {
+		logger.Warnf("teardown starts in %s, press Ctrl-C to abort", remaining)
+		wait := tick
+		if remaining < tick {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil
+}
+
+// Returns the upper bound on the time between stack status polls.
+func teardownMaxPollInterval() time.Duration {
+	value := os.Getenv("TEARDOWN_MAX_POLL_INTERVAL")
+	if value == "" {
+		return defaultMaxPollInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < pollInterval {
+		logger.Fatalf("invalid TEARDOWN_MAX_POLL_INTERVAL %q: must be a duration of at least %s", value, pollInterval)
+	}
+	return interval
+}
+
+// Returns the maximum number of stacks deleted at once (0 means no limit).
+func teardownConcurrency() int {
+	value := os.Getenv("TEARDOWN_CONCURRENCY")
+	if value == "" {
+		return 0
+	}
+	concurrency, err := strconv.Atoi(value)
+	if err != nil || concurrency < 1 {
+		logger.Fatalf("invalid TEARDOWN_CONCURRENCY %q: must be a positive integer", value)
+	}
+	return concurrency
+}
+
+// Require a second confirmation if the account is the management account of an AWS organization.
+func confirmManagementAccount(client organizationsiface.OrganizationsAPI, accountID string) {
+	if !needsManagementConfirmation(client, accountID) {
+		return
+	}
+
+	logger.Warnf("!!! Account %s may be the MANAGEMENT account of your AWS organization !!!", accountID)
+	logger.Warn("Panther should not be deployed here; make sure this is really the account you want to tear down")
+	result := prompt.Read("Enter the account id to confirm teardown of the management account: ", prompt.NonemptyValidator)
+	if strings.TrimSpace(result) != accountID {
+		logger.Fatal("teardown aborted")
+	}
+}
+
+// Returns true if the account is the management account of its organization, or if that cannot be determined.
+func needsManagementConfirmation(client organizationsiface.OrganizationsAPI, accountID string) bool {
+	isManagement, err := isManagementAccount(client, accountID)
+	if err != nil {
+		logger.Warnf("unable to check if account %s is an organization management account: %v", accountID, err)
+		return true
+	}
+	return isManagement
+}
+
+// Returns true if the account is the management account of the organization it belongs to.
+func isManagementAccount(client organizationsiface.OrganizationsAPI, accountID string) (bool, error) {
+	response, err := client.DescribeOrganization(&organizations.DescribeOrganizationInput{})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == organizations.ErrCodeAWSOrganizationsNotInUseException {
+			return false, nil
+		}
+		return false, err
+	}
+	return aws.StringValue(response.Organization.MasterAccountId) == accountID, nil
+}
+
+// Build a copy of the base session which uses credentials from assuming the given role.
+//
+// The role is assumed lazily, the first time the credentials are needed.
+func assumeRoleSession(base *session.Session, client stscreds.AssumeRoler, roleARN string) *session.Session {
+	return base.Copy(aws.NewConfig().WithCredentials(stscreds.NewCredentialsWithClient(client, roleARN)))
+}
+
+// Returns the identity of the current credentials, or an error if they are invalid or expired.
+func getCallerIdentity(client stsiface.STSAPI) (*sts.GetCallerIdentityOutput, error) {
+	identity, err := client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid or expired AWS credentials: %v", err)
+	}
+	return identity, nil
+}
+
+// Check the account of the current identity against the expected account (if any).
+func verifyAccount(identity *sts.GetCallerIdentityOutput, expectedAccountID string) error {
+	accountID := aws.StringValue(identity.Account)
+	if expectedAccountID != "" && accountID != expectedAccountID {
+		return fmt.Errorf("current account %s does not match expected account %s", accountID, expectedAccountID)
+	}
+	return nil
+}
+
 // Destroy all Panther CloudFormation stacks
-func destroyCfnStacks(masterStack string) error {
+//
+// Stacks which fail to delete are deleted again up to retainRetries times, retaining the failed resources.
+func destroyCfnStacks(masterStack string, stacks []string, maxPollInterval time.Duration, concurrency, retainRetries int,
+	summary *teardownResult) error {
+
 	client := cloudformation.New(awsSession)
+	if len(stacks) == 0 {
+		logger.Info("no CloudFormation stacks selected for deletion")
+		return nil
+	}
 	if masterStack != "" {
 		logger.Infof("deleting master stack '%s'", masterStack)
-		return deleteStack(client, &masterStack)
+		stackID, err := deleteStack(client, &masterStack, maxPollInterval, retainRetries)
+		summary.addStack(masterStack, stackID, err)
+		return err
 	}
 
 	// Define a common routine for processing stack delete results
 	var errCount, finishCount int
 	handleResult := func(result deleteStackResult) {
 		finishCount++
+		summary.addStack(result.stackName, result.stackID, result.err)
 		if result.err != nil {
 			logger.Errorf("    - %s failed to delete (%d/%d): %v",
-				result.stackName, finishCount, cfnstacks.NumStacks, result.err)
+				result.stackName, finishCount, len(stacks), result.err)
 			errCount++
 			return
 		}
 
-		logger.Infof("    √ %s deleted (%d/%d)", result.stackName, finishCount, cfnstacks.NumStacks)
+		logger.Infof("    √ %s deleted (%d/%d)", result.stackName, finishCount, len(stacks))
 	}
 
-	// Trigger the deletion of the main stacks in parallel
+	// Trigger the deletion of the main stacks in parallel (up to TEARDOWN_CONCURRENCY at once)
 	//
 	// The bootstrap stacks have to be last because of the ECS cluster and custom resource Lambda.
-	parallelStacks := []string{
-		cfnstacks.Appsync,
-		cfnstacks.Cloudsec,
-		cfnstacks.Core,
-		cfnstacks.Dashboard,
-		cfnstacks.Frontend,
-		cfnstacks.LogAnalysis,
-		cfnstacks.Onboard,
-	}
-	logger.Infof("deleting %d CloudFormation stacks", cfnstacks.NumStacks)
+	logger.Infof("deleting %d CloudFormation stacks", len(stacks))
 
-	deleteFunc := func(client *cloudformation.CloudFormation, stack string, r chan deleteStackResult) {
-		r <- deleteStackResult{stackName: stack, err: deleteStack(client, &stack)}
+	deleteFunc := func(stack string) (string, error) {
+		return deleteStack(client, &stack, maxPollInterval, retainRetries)
 	}
 
-	results := make(chan deleteStackResult)
+	// Stacks which export values imported by their siblings are deleted after the stacks importing them
+	var selectedParallel []string
 	for _, stack := range parallelStacks {
-		go deleteFunc(client, stack, results)
+		if containsString(stacks, stack) {
+			selectedParallel = append(selectedParallel, stack)
+		}
 	}
-
-	// Wait for all of the main stacks to finish deleting
-	for i := 0; i < len(parallelStacks); i++ {
-		handleResult(<-results)
+	for _, wave := range orderStackDeletion(client, selectedParallel) {
+		// Wait for all of the stacks in this wave to finish deleting
+		deleteStacksConcurrently(wave, concurrency, deleteFunc, handleResult)
 	}
 
 	// Now finish with the bootstrap stacks
 	// bootstrap-gateway must be deleted first because it will empty the ECR repo
-	go deleteFunc(client, cfnstacks.Gateway, results)
-	handleResult(<-results)
-	go deleteFunc(client, cfnstacks.Bootstrap, results)
-	handleResult(<-results)
+	for _, stack := range []string{cfnstacks.Gateway, cfnstacks.Bootstrap} {
+		if containsString(stacks, stack) {
+			stackID, err := deleteFunc(stack)
+			handleResult(deleteStackResult{stackName: stack, stackID: stackID, err: err})
+		}
+	}
 
 	if errCount > 0 {
 		return fmt.Errorf("%d stack(s) failed to delete", errCount)
@@ -147,24 +535,301 @@ func destroyCfnStacks(masterStack string) error {
 	return nil
 }
 
-// Delete a single CFN stack and wait for it to finish
-func deleteStack(client *cloudformation.CloudFormation, stack *string) error {
-	if _, err := client.DeleteStack(&cloudformation.DeleteStackInput{StackName: stack}); err != nil {
+// Delete the stacks with at most `concurrency` deletions in flight (all at once if concurrency <= 0).
+//
+// Results are passed to handleResult as they finish, it returns when all of the stacks are handled.
+func deleteStacksConcurrently(stacks []string, concurrency int, deleteFunc func(stack string) (string, error),
+	handleResult func(deleteStackResult)) {
+
+	if concurrency <= 0 || concurrency > len(stacks) {
+		concurrency = len(stacks)
+	}
+	results := make(chan deleteStackResult)
+	slots := make(chan struct{}, concurrency)
+	go func() {
+		for _, stack := range stacks {
+			slots <- struct{}{}
+			go func(stack string) {
+				stackID, err := deleteFunc(stack)
+				<-slots
+				results <- deleteStackResult{stackName: stack, stackID: stackID, err: err}
+			}(stack)
+		}
+	}()
+	for range stacks {
+		handleResult(<-results)
+	}
+}
+
+// Group stacks into waves which can be deleted in parallel, in order.
+//
+// A stack which exports values imported by other stacks in the list is placed in a later wave than its importers,
+// otherwise CloudFormation refuses to delete it. If the dependencies cannot be determined, all stacks are
+// returned in a single wave.
+func orderStackDeletion(client cloudformationiface.CloudFormationAPI, stacks []string) [][]string {
+	importers, err := listStackImporters(client, stacks)
+	if err != nil {
+		logger.Warnf("unable to determine stack dependencies, deleting stacks in parallel: %v", err)
+		return [][]string{stacks}
+	}
+
+	var waves [][]string
+	remaining := stacks
+	deleted := make(map[string]bool, len(stacks))
+	for len(remaining) > 0 {
+		var wave, next []string
+		for _, stack := range remaining {
+			ready := true
+			for importer := range importers[stack] {
+				if !deleted[importer] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, stack)
+			} else {
+				next = append(next, stack)
+			}
+		}
+		if len(wave) == 0 {
+			logger.Warnf("circular stack dependencies between %v, deleting stacks in parallel", remaining)
+			return [][]string{stacks}
+		}
+		for _, stack := range wave {
+			deleted[stack] = true
+		}
+		waves = append(waves, wave)
+		remaining = next
+	}
+	return waves
+}
+
+// Returns a map from each stack to the set of other stacks in the list importing its exports.
+func listStackImporters(client cloudformationiface.CloudFormationAPI, stacks []string) (map[string]map[string]bool, error) {
+	inList := make(map[string]bool, len(stacks))
+	for _, stack := range stacks {
+		inList[stack] = true
+	}
+
+	// Exports of the stacks in the list, keyed by export name
+	exporters := make(map[string]string)
+	err := client.ListExportsPages(&cloudformation.ListExportsInput{},
+		func(page *cloudformation.ListExportsOutput, lastPage bool) bool {
+			for _, export := range page.Exports {
+				if stack := stackNameFromID(aws.StringValue(export.ExportingStackId)); inList[stack] {
+					exporters[aws.StringValue(export.Name)] = stack
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stack exports: %v", err)
+	}
+
+	importers := make(map[string]map[string]bool)
+	for exportName, exporter := range exporters {
+		err := client.ListImportsPages(&cloudformation.ListImportsInput{ExportName: aws.String(exportName)},
+			func(page *cloudformation.ListImportsOutput, lastPage bool) bool {
+				for _, importer := range page.Imports {
+					if stack := aws.StringValue(importer); inList[stack] && stack != exporter {
+						if importers[exporter] == nil {
+							importers[exporter] = make(map[string]bool)
+						}
+						importers[exporter][stack] = true
+					}
+				}
+				return true
+			})
+		if err != nil {
+			// ListImports returns a ValidationError if the export is not imported by any stack
+			if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "ValidationError" &&
+				strings.Contains(awsErr.Message(), "is not imported") {
+
+				continue
+			}
+			return nil, fmt.Errorf("failed to list imports of %s: %v", exportName, err)
+		}
+	}
+	return importers, nil
+}
+
+// Returns the stack name from a stack ID (arn:aws:cloudformation:region:account:stack/name/uuid)
+func stackNameFromID(stackID string) string {
+	parts := strings.Split(stackID, "/")
+	if len(parts) < 2 {
+		return stackID
+	}
+	return parts[1]
+}
+
+// Post the teardown summary as JSON to the given webhook URL.
+func notifyWebhook(client *http.Client, url string, summary *teardownResult) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
 		return err
 	}
 
-	_, err := awscfn.WaitForStackDelete(client, logger, *stack, pollInterval)
-	return err
+	response, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %s", url, response.Status)
+	}
+	return nil
+}
+
+// Delete a single CFN stack and wait for it to finish, returning its stack id.
+//
+// The stack status is polled often at first, then less frequently (up to maxPollInterval) to avoid
+// throttling DescribeStacks when many stacks take a long time to delete.
+func deleteStack(client *cloudformation.CloudFormation, stack *string, maxPollInterval time.Duration,
+	retainRetries int) (string, error) {
+
+	// Deleted stacks can't be described by name, look up the id for the audit record first (best effort)
+	var stackID string
+	if response, err := client.DescribeStacks(&cloudformation.DescribeStacksInput{StackName: stack}); err == nil &&
+		len(response.Stacks) > 0 {
+
+		stackID = aws.StringValue(response.Stacks[0].StackId)
+	}
+
+	wait := func() error {
+		_, err := awscfn.WaitForStackDeleteBackoff(client, logger, *stack,
+			awscfn.ExponentialBackoff(pollInterval, maxPollInterval))
+		return err
+	}
+	return stackID, deleteStackRetaining(client, *stack, retainRetries, wait)
 }
 
-// Delete all objects in the given S3 buckets and then remove them.
-func destroyPantherBuckets() {
-	client := s3.New(awsSession)
+// Delete a stack and wait for the deletion to finish.
+//
+// If the stack ends up in DELETE_FAILED, the delete is retried up to `retries` times, retaining the resources
+// which failed to delete in the previous attempt. Retained resources are orphaned and have to be cleaned up manually.
+func deleteStackRetaining(client cloudformationiface.CloudFormationAPI, stack string, retries int,
+	wait func() error) error {
+
+	input := &cloudformation.DeleteStackInput{StackName: &stack}
+	var retained []string
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		if _, err := client.DeleteStack(input); err != nil {
+			return err
+		}
+		err := wait()
+		if err == nil {
+			if len(retained) > 0 {
+				logger.Warnf("%s deleted, the retained resources were orphaned: %s",
+					stack, strings.Join(retained, ", "))
+			}
+			return nil
+		}
+		var statusErr *awscfn.StackStatusError
+		if !errors.As(err, &statusErr) || statusErr.Status != cloudformation.StackStatusDeleteFailed || attempt >= retries {
+			return err
+		}
+
+		failed, listErr := listDeleteFailedResources(client, stack, start)
+		if listErr != nil {
+			return fmt.Errorf("%v (failed to list the resources to retain: %v)", err, listErr)
+		}
+		if len(failed) == 0 {
+			return err
+		}
+		logger.Warnf("%s: retrying delete, retaining %d failed resource(s): %s",
+			stack, len(failed), strings.Join(failed, ", "))
+		for _, logicalID := range failed {
+			if !containsString(retained, logicalID) {
+				retained = append(retained, logicalID)
+			}
+		}
+		input = &cloudformation.DeleteStackInput{StackName: &stack, RetainResources: aws.StringSlice(retained)}
+	}
+}
+
+// Returns the logical ids of the stack resources which failed to delete since the given time.
+func listDeleteFailedResources(client cloudformationiface.CloudFormationAPI, stack string,
+	since time.Time) ([]string, error) {
+
+	var failed []string
+	seen := make(map[string]bool)
+	err := client.DescribeStackEventsPages(&cloudformation.DescribeStackEventsInput{StackName: &stack},
+		func(page *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+			// Events are returned in reverse chronological order
+			for _, event := range page.StackEvents {
+				if aws.TimeValue(event.Timestamp).Before(since) {
+					return false
+				}
+				logicalID := aws.StringValue(event.LogicalResourceId)
+				if aws.StringValue(event.ResourceStatus) != cloudformation.ResourceStatusDeleteFailed ||
+					logicalID == stack || seen[logicalID] {
+
+					continue
+				}
+				seen[logicalID] = true
+				failed = append(failed, logicalID)
+			}
+			return true
+		})
+	return failed, err
+}
+
+// Delete all objects in the selected Panther S3 buckets and then remove them (unless emptyOnly is set).
+func destroyPantherBuckets(client s3iface.S3API, masterStack string, selection *bucketSelection, emptyOnly bool,
+	summary *teardownResult) error {
+
+	buckets, err := listPantherBuckets(client, masterStack, selection)
+	if err != nil {
+		return err
+	}
+
+	var errCount int
+	for _, bucket := range buckets {
+		objectVersions, err := removeBucket(client, bucket, emptyOnly)
+		summary.addBucket(*bucket, objectVersions, emptyOnly, err)
+		if _, ok := err.(*manualBucketError); ok {
+			// Not a failure, the bucket is skipped and left for the operator
+			logger.Warnf("    - skipping %v", err)
+			continue
+		}
+		if err != nil {
+			logger.Errorf("    - %s failed to delete: %v", *bucket, err)
+			errCount++
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("%d bucket(s) failed to delete", errCount)
+	}
+	return nil
+}
+
+// Log the selected Panther S3 buckets which are left in place (RETAIN_BUCKETS).
+//
+// The buckets are only listed for the summary, so a listing failure is not a teardown failure.
+func retainPantherBuckets(client s3iface.S3API, masterStack string, selection *bucketSelection, summary *teardownResult) {
+	buckets, err := listPantherBuckets(client, masterStack, selection)
+	if err != nil {
+		logger.Warnf("failed to list retained S3 buckets: %v", err)
+		return
+	}
+	for _, bucket := range buckets {
+		logger.Infof("    - retaining %s", *bucket)
+		summary.BucketsRetained = append(summary.BucketsRetained, *bucket)
+	}
+}
+
+// Returns the names of the selected S3 buckets created by Panther (all of them if selection is nil).
+func listPantherBuckets(client s3iface.S3API, masterStack string, selection *bucketSelection) ([]*string, error) {
 	response, err := client.ListBuckets(&s3.ListBucketsInput{})
 	if err != nil {
-		logger.Fatalf("failed to list S3 buckets: %v", err)
+		return nil, fmt.Errorf("failed to list S3 buckets: %v", err)
 	}
 
+	var buckets []*string
 	for _, bucket := range response.Buckets {
 		response, err := client.GetBucketTagging(&s3.GetBucketTaggingInput{Bucket: bucket.Name})
 		if err != nil {
@@ -172,61 +837,111 @@ func destroyPantherBuckets() {
 			continue
 		}
 
-		var hasApplicationTag, hasStackTag bool
-		for _, tag := range response.TagSet {
-			switch aws.StringValue(tag.Key) {
-			case "Application":
-				hasApplicationTag = aws.StringValue(tag.Value) == "Panther"
-			case "Stack":
-				hasStackTag = aws.StringValue(tag.Value) == "panther-bootstrap"
-			}
-		}
-
 		// S3 bucket names are not predictable, and neither are stack names (when using master template).
 		// However, both 'mage deploy' and the master template have these tags set.
-		if hasApplicationTag && hasStackTag {
-			removeBucket(client, bucket.Name)
+		if isPantherBucket(response.TagSet, masterStack) && selection.includes(*bucket.Name, response.TagSet) {
+			buckets = append(buckets, bucket.Name)
+		}
+	}
+	return buckets, nil
+}
+
+// Returns true if the bucket tags indicate it was created by Panther.
+//
+// The Application tag is always "Panther". The Stack tag is "panther-bootstrap" when deploying from source,
+// but when deploying the master template it is the name of the nested stack, "<master stack>-<logical id>-<suffix>".
+func isPantherBucket(tags []*s3.Tag, masterStack string) bool {
+	var hasApplicationTag, hasStackTag bool
+	for _, tag := range tags {
+		switch value := aws.StringValue(tag.Value); aws.StringValue(tag.Key) {
+		case "Application":
+			hasApplicationTag = value == "Panther"
+		case "Stack":
+			hasStackTag = value == cfnstacks.Bootstrap || isNestedStackOf(value, masterStack)
 		}
 	}
+	return hasApplicationTag && hasStackTag
 }
 
-// Empty, then delete the given S3 bucket.
+// Returns true if stackName is a nested stack of masterStack.
+//
+// Logical ids are alphanumeric, so the name of a sibling deployment's nested stack (i.e. "panther-dev-Bootstrap-X"
+// when the master stack is "panther") has extra dashes after the master stack name.
+func isNestedStackOf(stackName, masterStack string) bool {
+	if masterStack == "" || !strings.HasPrefix(stackName, masterStack+"-") {
+		return false
+	}
+	return strings.Count(stackName[len(masterStack)+1:], "-") == 1
+}
+
+// A bucket teardown can't empty by itself, which is skipped and left for the operator to delete manually.
+type manualBucketError struct {
+	bucketName string
+	reason     string
+}
+
+func (e *manualBucketError) Error() string {
+	return fmt.Sprintf("s3://%s: %s, empty and delete it manually", e.bucketName, e.reason)
+}
+
+// Returns true if the error is an S3 AccessDenied error.
+func isAccessDenied(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == "AccessDenied"
+}
+
+// Returns a manualBucketError if the bucket has requester pays enabled.
+//
+// This is best effort, if the payment configuration can't be read we try to empty the bucket anyway.
+func checkRequesterPays(client s3iface.S3API, bucketName *string) error {
+	response, err := client.GetBucketRequestPayment(&s3.GetBucketRequestPaymentInput{Bucket: bucketName})
+	if err != nil {
+		logger.Debugf("failed to get request payment configuration of %s: %v", *bucketName, err)
+		return nil
+	}
+	if aws.StringValue(response.Payer) == s3.PayerRequester {
+		return &manualBucketError{
+			bucketName: *bucketName,
+			reason:     "requester pays is enabled (disable it with 'aws s3api put-bucket-request-payment')",
+		}
+	}
+	return nil
+}
+
+// Empty, then delete the given S3 bucket. If emptyOnly is set, the empty bucket is left in place.
 //
 // Or, if there are too many objects to delete directly, set a 1-day expiration lifecycle policy instead.
-func removeBucket(client *s3.S3, bucketName *string) {
+// Buckets with requester pays enabled, an ACL or objects we are denied access to (i.e. owned by another account)
+// are skipped with a manualBucketError.
+// Returns the number of object versions found in the bucket.
+func removeBucket(client s3iface.S3API, bucketName *string, emptyOnly bool) (int, error) {
+	// Check before modifying anything, so skipped buckets are left exactly as they were
+	if err := checkRequesterPays(client, bucketName); err != nil {
+		return 0, err
+	}
+
 	// Prevent new writes to the bucket
 	_, err := client.PutBucketAcl(&s3.PutBucketAclInput{ACL: aws.String("private"), Bucket: bucketName})
 	if err != nil {
 		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "NoSuchBucket" {
 			logger.Debugf("%s already deleted", *bucketName)
-			return
+			return 0, nil
 		}
-		logger.Fatalf("%s put-bucket-acl failed: %v", *bucketName, err)
-	}
-
-	input := &s3.ListObjectVersionsInput{Bucket: bucketName}
-	var objectVersions []*s3.ObjectIdentifier
-
-	// List all object versions (including delete markers)
-	err = client.ListObjectVersionsPages(input, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
-		for _, marker := range page.DeleteMarkers {
-			objectVersions = append(objectVersions, &s3.ObjectIdentifier{
-				Key: marker.Key, VersionId: marker.VersionId})
-		}
-
-		for _, version := range page.Versions {
-			objectVersions = append(objectVersions, &s3.ObjectIdentifier{
-				Key: version.Key, VersionId: version.VersionId})
+		if isAccessDenied(err) {
+			return 0, &manualBucketError{
+				bucketName: *bucketName,
+				reason:     "access denied updating the bucket ACL",
+			}
 		}
+		return 0, fmt.Errorf("%s put-bucket-acl failed: %v", *bucketName, err)
+	}
 
-		// Keep paging as long as we don't have too many items yet
-		return len(objectVersions) < s3MaxDeletes
-	})
+	objectVersions, err := listObjectVersions(client, bucketName)
 	if err != nil {
-		logger.Fatalf("failed to list object versions for %s: %v", *bucketName, err)
+		return 0, err
 	}
 
-	if len(objectVersions) >= s3MaxDeletes {
+	if useExpirationPolicy(len(objectVersions)) {
 		logger.Warnf("s3://%s has too many items to delete directly, setting an expiration policy instead", *bucketName)
 		_, err = client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
 			Bucket: bucketName,
@@ -252,7 +967,7 @@ func removeBucket(client *s3.S3, bucketName *string) {
 			},
 		})
 		if err != nil {
-			logger.Fatalf("failed to set expiration policy for %s: %v", *bucketName, err)
+			return 0, fmt.Errorf("failed to set expiration policy for %s: %v", *bucketName, err)
 		}
 		// remove any notifications since we are leaving the bucket (best effort)
 		notificationInput := &s3.PutBucketNotificationConfigurationInput{
@@ -264,20 +979,102 @@ func removeBucket(client *s3.S3, bucketName *string) {
 			logger.Warnf("Unable to clear S3 event notifications on bucket %s (%v). Use the console to clear.",
 				bucketName, err)
 		}
-		return
+		return len(objectVersions), nil
 	}
 
 	// Here there aren't too many objects, we can delete them in a handful of BatchDelete calls.
-	logger.Infof("deleting s3://%s", *bucketName)
-	err = s3batch.DeleteObjects(client, 2*time.Minute, &s3.DeleteObjectsInput{
-		Bucket: bucketName,
-		Delete: &s3.Delete{Objects: objectVersions},
+	if emptyOnly {
+		logger.Infof("emptying s3://%s", *bucketName)
+	} else {
+		logger.Infof("deleting s3://%s", *bucketName)
+	}
+	if len(objectVersions) > 0 {
+		err = s3batch.DeleteObjects(client, 2*time.Minute, &s3.DeleteObjectsInput{
+			Bucket: bucketName,
+			Delete: &s3.Delete{Objects: objectVersions},
+		})
+		if isAccessDenied(err) {
+			return 0, &manualBucketError{
+				bucketName: *bucketName,
+				reason:     "access denied deleting objects (they may be owned by another account)",
+			}
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to batch delete objects: %v", err)
+		}
+	}
+	if emptyOnly {
+		return len(objectVersions), nil
+	}
+	return len(objectVersions), deleteEmptyBucket(client, bucketName, deleteBucketBackoff)
+}
+
+// List the object versions (including delete markers) in a bucket, up to about s3MaxDeletes.
+func listObjectVersions(client s3iface.S3API, bucketName *string) ([]*s3.ObjectIdentifier, error) {
+	input := &s3.ListObjectVersionsInput{Bucket: bucketName}
+	var objectVersions []*s3.ObjectIdentifier
+	err := client.ListObjectVersionsPages(input, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, marker := range page.DeleteMarkers {
+			objectVersions = append(objectVersions, &s3.ObjectIdentifier{
+				Key: marker.Key, VersionId: marker.VersionId})
+		}
+
+		for _, version := range page.Versions {
+			objectVersions = append(objectVersions, &s3.ObjectIdentifier{
+				Key: version.Key, VersionId: version.VersionId})
+		}
+
+		// Keep paging as long as we don't have too many items yet
+		return len(objectVersions) < s3MaxDeletes
 	})
+	if isAccessDenied(err) {
+		return nil, &manualBucketError{
+			bucketName: *bucketName,
+			reason:     "access denied listing object versions (check the bucket policy and ACLs)",
+		}
+	}
 	if err != nil {
-		logger.Fatalf("failed to batch delete objects: %v", err)
+		return nil, fmt.Errorf("failed to list object versions for %s: %v", *bucketName, err)
+	}
+	return objectVersions, nil
+}
+
+// Returns true if a bucket has too many object versions to delete directly.
+func useExpirationPolicy(objectVersions int) bool {
+	return objectVersions >= s3MaxDeletes
+}
+
+// Delete a bucket whose objects were just deleted, retrying (with exponential backoff) until it is empty.
+func deleteEmptyBucket(client s3iface.S3API, bucketName *string, backoff time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		empty, err := isBucketEmpty(client, bucketName)
+		if err != nil {
+			return err
+		}
+		if empty {
+			_, err = client.DeleteBucket(&s3.DeleteBucketInput{Bucket: bucketName})
+			if err == nil {
+				return nil
+			}
+			if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != "BucketNotEmpty" {
+				return fmt.Errorf("failed to delete bucket %s: %v", *bucketName, err)
+			}
+		}
+
+		if attempt == deleteBucketAttempts {
+			return fmt.Errorf("failed to delete bucket %s: still not empty after %d attempts", *bucketName, attempt)
+		}
+		logger.Debugf("s3://%s is not empty yet, retrying in %s", *bucketName, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
-	time.Sleep(time.Second) // short pause since S3 is eventually consistent to avoid next call from failing
-	if _, err = client.DeleteBucket(&s3.DeleteBucketInput{Bucket: bucketName}); err != nil {
-		logger.Fatalf("failed to delete bucket %s: %v", *bucketName, err)
+}
+
+// Returns true if the bucket has no object versions or delete markers left.
+func isBucketEmpty(client s3iface.S3API, bucketName *string) (bool, error) {
+	response, err := client.ListObjectVersions(&s3.ListObjectVersionsInput{Bucket: bucketName, MaxKeys: aws.Int64(1)})
+	if err != nil {
+		return false, fmt.Errorf("failed to list object versions for %s: %v", *bucketName, err)
 	}
+	return len(response.Versions) == 0 && len(response.DeleteMarkers) == 0, nil
 }
@@ -0,0 +1,112 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// The teardown audit record is always written here, and uploaded to AUDIT_UPLOAD_BUCKET if set.
+const auditFile = "out/teardown-audit.json"
+
+// Resource types in the audit record, besides the TEARDOWN_CONFIG extras categories (glue, ecr, iam).
+const (
+	auditTypeStack  = "stack"
+	auditTypeBucket = "bucket"
+)
+
+// What happened to a resource in the audit record.
+const (
+	auditActionDeleted = "deleted"
+	// The bucket was emptied but left in place (EMPTY_ONLY)
+	auditActionEmptied = "emptied"
+	// The bucket had too many objects to delete, an expiration policy will remove them
+	auditActionExpiring = "expiring"
+)
+
+// A single resource removed by teardown.
+type auditRecord struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	// Number of object versions found in a bucket
+	Objects   int       `json:"objects,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Add a resource to the audit trail, timestamped now.
+func (r *teardownResult) audit(record auditRecord) {
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now().UTC()
+	}
+	r.Audit = append(r.Audit, record)
+}
+
+// Write the teardown summary with its audit trail to the local audit file and AUDIT_UPLOAD_BUCKET (if set).
+//
+// This is best effort: failing to save the audit record is not a failed teardown.
+func writeAuditRecord(summary *teardownResult) {
+	body, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		logger.Warnf("failed to marshal teardown audit record: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(auditFile), 0755); err != nil {
+		logger.Warnf("failed to write teardown audit record: %v", err)
+	} else if err := ioutil.WriteFile(auditFile, body, 0644); err != nil {
+		logger.Warnf("failed to write teardown audit record: %v", err)
+	} else {
+		logger.Infof("teardown audit record written to %s", auditFile)
+	}
+
+	if bucket := os.Getenv("AUDIT_UPLOAD_BUCKET"); bucket != "" {
+		key, err := uploadAuditRecord(s3.New(awsSession), bucket, summary, body)
+		if err != nil {
+			logger.Warnf("failed to upload teardown audit record: %v", err)
+			return
+		}
+		logger.Infof("teardown audit record uploaded to s3://%s/%s", bucket, key)
+	}
+}
+
+// Upload the marshaled audit record to the given bucket, returning the object key.
+//
+// Records are keyed by account, region and start time so that repeated teardowns do not overwrite each other.
+func uploadAuditRecord(client s3iface.S3API, bucket string, summary *teardownResult, body []byte) (string, error) {
+	key := fmt.Sprintf("panther-teardown/%s/%s/%s.json",
+		summary.Account, summary.Region, summary.StartedAt.Format("20060102T150405Z"))
+	_, err := client.PutObject(&s3.PutObjectInput{
+		Body:        bytes.NewReader(body),
+		Bucket:      aws.String(bucket),
+		ContentType: aws.String("application/json"),
+		Key:         aws.String(key),
+	})
+	return key, err
+}
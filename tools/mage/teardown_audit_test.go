@@ -0,0 +1,113 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/pkg/testutils"
+)
+
+func TestTeardownAuditRecord(t *testing.T) {
+	start := time.Now().UTC()
+	var summary teardownResult
+	summary.addStack("panther-core", "arn:aws:cloudformation:us-west-2:111122223333:stack/panther-core/1234", nil)
+	summary.addStack("panther-log-analysis", "", nil)
+	summary.addStack("panther-onboard", "", errors.New("DELETE_FAILED"))
+	summary.addBucket("panther-data", 2, false, nil)
+	summary.addBucket("panther-logs", 2, true, nil)
+	summary.addBucket("panther-history", s3MaxDeletes, false, nil)
+	summary.addBucket("panther-analysis", 0, false, errors.New("access denied"))
+	summary.addExtra(extraIAM, "panther-role", nil)
+	summary.addExtra(extraECR, "panther-repo", errors.New("access denied"))
+
+	for _, record := range summary.Audit {
+		assert.False(t, record.Timestamp.Before(start))
+		assert.Equal(t, time.UTC, record.Timestamp.Location())
+	}
+	for i := range summary.Audit {
+		summary.Audit[i].Timestamp = time.Time{}
+	}
+	// Only resources which were actually removed are in the audit trail
+	assert.Equal(t, []auditRecord{
+		{Type: "stack", ID: "arn:aws:cloudformation:us-west-2:111122223333:stack/panther-core/1234", Action: "deleted"},
+		{Type: "stack", ID: "panther-log-analysis", Action: "deleted"},
+		{Type: "bucket", ID: "panther-data", Action: "deleted", Objects: 2},
+		{Type: "bucket", ID: "panther-logs", Action: "emptied", Objects: 2},
+		{Type: "bucket", ID: "panther-history", Action: "expiring", Objects: s3MaxDeletes},
+		{Type: "iam", ID: "panther-role", Action: "deleted"},
+	}, summary.Audit)
+	assert.Equal(t, []string{"iam/panther-role"}, summary.ExtrasDeleted)
+	assert.Equal(t, []string{"ecr/panther-repo"}, summary.ExtrasFailed)
+}
+
+func TestUploadAuditRecord(t *testing.T) {
+	summary := &teardownResult{
+		Account:   testAccountID,
+		Region:    "us-west-2",
+		StartedAt: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+	summary.addBucket("panther-data", 2, false, nil)
+	body, err := json.Marshal(summary)
+	require.NoError(t, err)
+
+	client := &testutils.S3Mock{}
+	var uploaded []byte
+	client.On("PutObject", mock.Anything).Return(&s3.PutObjectOutput{}, nil).Once().Run(func(args mock.Arguments) {
+		input := args.Get(0).(*s3.PutObjectInput)
+		assert.Equal(t, "audit-bucket", aws.StringValue(input.Bucket))
+		assert.Equal(t, "panther-teardown/111122223333/us-west-2/20200102T150405Z.json", aws.StringValue(input.Key))
+		assert.Equal(t, "application/json", aws.StringValue(input.ContentType))
+		uploaded, err = ioutil.ReadAll(input.Body)
+		require.NoError(t, err)
+	})
+
+	key, err := uploadAuditRecord(client, "audit-bucket", summary, body)
+	require.NoError(t, err)
+	assert.Equal(t, "panther-teardown/111122223333/us-west-2/20200102T150405Z.json", key)
+	client.AssertExpectations(t)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(uploaded, &record))
+	assert.Equal(t, "2020-01-02T15:04:05Z", record["startedAt"])
+	require.Len(t, record["audit"], 1)
+	audit := record["audit"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "bucket", audit["type"])
+	assert.Equal(t, "panther-data", audit["id"])
+	assert.Equal(t, float64(2), audit["objects"])
+}
+
+func TestUploadAuditRecordFailure(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("PutObject", mock.Anything).Return(&s3.PutObjectOutput{}, errors.New("access denied")).Once()
+
+	_, err := uploadAuditRecord(client, "audit-bucket", &teardownResult{}, []byte("{}"))
+	require.Error(t, err)
+	client.AssertExpectations(t)
+}
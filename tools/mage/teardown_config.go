@@ -0,0 +1,282 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gopkg.in/yaml.v2"
+
+	"github.com/panther-labs/panther/tools/cfnstacks"
+)
+
+// Extra resource categories which are not deleted by CloudFormation, see Inventory.
+const (
+	extraGlue = "glue"
+	extraECR  = "ecr"
+	extraIAM  = "iam"
+)
+
+var extraCategories = []string{extraGlue, extraECR, extraIAM}
+
+// Resource selection for teardown, read from the YAML (or JSON) file at TEARDOWN_CONFIG.
+//
+//	Stacks:
+//	  Retain: [panther-bootstrap, panther-bootstrap-gateway]
+//	Buckets:
+//	  Tags: {Stack: panther-bootstrap}
+//	  Retain: [panther-bootstrap-auditlogs-123]
+//	Extras:
+//	  Delete: [glue, ecr]
+type teardownConfig struct {
+	Stacks  resourceSelection `yaml:"Stacks"`
+	Buckets bucketSelection   `yaml:"Buckets"`
+	Extras  resourceSelection `yaml:"Extras"`
+}
+
+// Resources to delete (all of them if empty) and resources to keep.
+type resourceSelection struct {
+	Delete []string `yaml:"Delete"`
+	Retain []string `yaml:"Retain"`
+}
+
+// Panther buckets to delete by name, and by tags which must all match.
+type bucketSelection struct {
+	Delete []string          `yaml:"Delete"`
+	Retain []string          `yaml:"Retain"`
+	Tags   map[string]string `yaml:"Tags"`
+}
+
+// What teardown will delete
+type teardownPlan struct {
+	// Top-level stacks, in the order of pantherStackNames
+	stacks []string
+	// nil means all Panther buckets
+	buckets *bucketSelection
+	// Extra resource categories
+	extras []string
+	// Set if STACKS excludes the bootstrap stack, the buckets and extra resources are then left intact
+	stacksOnly bool
+}
+
+// Returns the teardown plan for the TEARDOWN_CONFIG file, or the default plan if it is not set.
+//
+// The stacks are further restricted to the STACKS subset if set.
+func teardownPlanFromEnv() (*teardownPlan, error) {
+	masterStack := os.Getenv("STACK")
+	plan, err := teardownPlanFromConfig(masterStack, os.Getenv("TEARDOWN_CONFIG"))
+	if err != nil {
+		return nil, err
+	}
+	if value := os.Getenv("STACKS"); value != "" {
+		if err := plan.selectStacks(masterStack, value); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+func teardownPlanFromConfig(masterStack, path string) (*teardownPlan, error) {
+	if path == "" {
+		return defaultTeardownPlan(masterStack), nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TEARDOWN_CONFIG: %v", err)
+	}
+	config, err := parseTeardownConfig(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TEARDOWN_CONFIG %s: %v", path, err)
+	}
+	return config.plan(masterStack)
+}
+
+// Restrict the plan to a comma-separated subset of the top-level stacks (STACKS),
+// i.e. "panther-log-analysis,panther-cloud-security".
+//
+// Unknown names are an error so that a typo aborts teardown before anything is deleted.
+// The stacks keep the order of pantherStackNames, so the bootstrap stacks are still deleted last.
+// Unless the bootstrap stack is selected, the buckets and extra resources are not deleted:
+// the remaining stacks may still be using them.
+func (p *teardownPlan) selectStacks(masterStack, value string) error {
+	if masterStack != "" {
+		return fmt.Errorf("STACKS can not be used with master stack '%s'", masterStack)
+	}
+	var selection resourceSelection
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			selection.Delete = append(selection.Delete, name)
+		}
+	}
+	if len(selection.Delete) == 0 {
+		return fmt.Errorf("STACKS: no stack names in %q", value)
+	}
+	selected, err := selection.apply("STACKS", pantherStackNames(""))
+	if err != nil {
+		return err
+	}
+
+	var stacks []string
+	for _, stack := range p.stacks {
+		if containsString(selected, stack) {
+			stacks = append(stacks, stack)
+		}
+	}
+	p.stacks = stacks
+	p.stacksOnly = !containsString(stacks, cfnstacks.Bootstrap)
+	return nil
+}
+
+// Delete all stacks and Panther buckets, keep the extra resources.
+func defaultTeardownPlan(masterStack string) *teardownPlan {
+	return &teardownPlan{stacks: pantherStackNames(masterStack)}
+}
+
+// Parse a teardown config, failing on unknown keys.
+func parseTeardownConfig(body []byte) (*teardownConfig, error) {
+	var config teardownConfig
+	if err := yaml.UnmarshalStrict(bytes.TrimSpace(body), &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Validate the selection against the known resources and build the teardown plan.
+func (c *teardownConfig) plan(masterStack string) (*teardownPlan, error) {
+	stacks, err := c.Stacks.apply("Stacks", pantherStackNames(masterStack))
+	if err != nil {
+		return nil, err
+	}
+	extras, err := c.Extras.apply("Extras", extraCategories)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Extras.Delete) == 0 {
+		// Unlike stacks and buckets, extra resources are only deleted if explicitly selected
+		extras = nil
+	}
+	if err := checkOverlap("Buckets", c.Buckets.Delete, c.Buckets.Retain); err != nil {
+		return nil, err
+	}
+
+	plan := teardownPlan{stacks: stacks, extras: extras}
+	if len(c.Buckets.Delete)+len(c.Buckets.Retain)+len(c.Buckets.Tags) > 0 {
+		buckets := c.Buckets
+		plan.buckets = &buckets
+	}
+	return &plan, nil
+}
+
+// Returns the known resources which are selected, in their original order.
+func (s *resourceSelection) apply(kind string, known []string) ([]string, error) {
+	for _, name := range append(append([]string{}, s.Delete...), s.Retain...) {
+		if !containsString(known, name) {
+			return nil, fmt.Errorf("%s: unknown name %q, expected one of %s", kind, name, strings.Join(known, ", "))
+		}
+	}
+	if err := checkOverlap(kind, s.Delete, s.Retain); err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, name := range known {
+		if len(s.Delete) > 0 && !containsString(s.Delete, name) {
+			continue
+		}
+		if containsString(s.Retain, name) {
+			continue
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+// Returns true if the Panther bucket with the given name and tags is selected for deletion.
+//
+// A nil selection selects all buckets.
+func (s *bucketSelection) includes(name string, tags []*s3.Tag) bool {
+	if s == nil {
+		return true
+	}
+	if len(s.Delete) > 0 && !containsString(s.Delete, name) {
+		return false
+	}
+	if containsString(s.Retain, name) {
+		return false
+	}
+	for key, value := range s.Tags {
+		if !hasTag(tags, key, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasTag(tags []*s3.Tag, key, value string) bool {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key && aws.StringValue(tag.Value) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func checkOverlap(kind string, deleted, retained []string) error {
+	for _, name := range deleted {
+		if containsString(retained, name) {
+			return fmt.Errorf("%s: %q is both deleted and retained", kind, name)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Log what teardown will delete and retain.
+func (p *teardownPlan) log(masterStack string) {
+	for _, stack := range pantherStackNames(masterStack) {
+		if !containsString(p.stacks, stack) {
+			logger.Infof("retaining stack %s", stack)
+		}
+	}
+	if p.stacksOnly {
+		logger.Infof("retaining S3 buckets and leftover resources, %s is not in STACKS", cfnstacks.Bootstrap)
+		return
+	}
+	if p.buckets != nil {
+		logger.Infof("deleting only the S3 buckets matching TEARDOWN_CONFIG")
+	}
+	for _, extra := range p.extras {
+		logger.Infof("deleting leftover %s resources", extra)
+	}
+}
@@ -0,0 +1,158 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/tools/cfnstacks"
+)
+
+const testTeardownConfig = `
+Stacks:
+  Retain: [panther-bootstrap, panther-bootstrap-gateway]
+Buckets:
+  Tags: {Stack: panther-bootstrap}
+  Retain: [panther-bootstrap-auditlogs-123]
+Extras:
+  Delete: [glue, ecr]
+`
+
+func TestTeardownConfigPlan(t *testing.T) {
+	config, err := parseTeardownConfig([]byte(testTeardownConfig))
+	require.NoError(t, err)
+
+	plan, err := config.plan("")
+	require.NoError(t, err)
+	assert.Equal(t, &teardownPlan{
+		stacks: parallelStacks,
+		buckets: &bucketSelection{
+			Retain: []string{"panther-bootstrap-auditlogs-123"},
+			Tags:   map[string]string{"Stack": "panther-bootstrap"},
+		},
+		extras: []string{extraGlue, extraECR},
+	}, plan)
+
+	assert.True(t, plan.buckets.includes("panther-bootstrap-analysis-123",
+		testTags("Application", "Panther", "Stack", "panther-bootstrap")))
+	assert.False(t, plan.buckets.includes("panther-bootstrap-auditlogs-123",
+		testTags("Application", "Panther", "Stack", "panther-bootstrap")))
+	assert.False(t, plan.buckets.includes("panther-other-123", testTags("Application", "Panther", "Stack", "other")))
+}
+
+func TestTeardownConfigJSON(t *testing.T) {
+	config, err := parseTeardownConfig([]byte(`{"Stacks": {"Delete": ["panther-web", "panther-core"]}}`))
+	require.NoError(t, err)
+
+	plan, err := config.plan("")
+	require.NoError(t, err)
+	// Stacks keep the teardown order
+	assert.Equal(t, &teardownPlan{stacks: []string{cfnstacks.Core, cfnstacks.Frontend}}, plan)
+}
+
+func TestTeardownConfigMasterStack(t *testing.T) {
+	config, err := parseTeardownConfig([]byte("Buckets:\n  Delete: [panther-data]\n"))
+	require.NoError(t, err)
+
+	plan, err := config.plan("panther")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"panther"}, plan.stacks)
+	assert.True(t, plan.buckets.includes("panther-data", nil))
+	assert.False(t, plan.buckets.includes("panther-logs", nil))
+	assert.Empty(t, plan.extras)
+
+	// Only the master stack is known
+	config, err = parseTeardownConfig([]byte("Stacks:\n  Retain: [panther-core]\n"))
+	require.NoError(t, err)
+	_, err = config.plan("panther")
+	require.Error(t, err)
+}
+
+func TestTeardownConfigInvalid(t *testing.T) {
+	for _, body := range []string{
+		"Functions:\n  Delete: [panther-api]\n", // unknown category
+		"Stacks:\n  Remove: [panther-core]\n",   // unknown key
+		"Buckets:\n  Names: [panther-data]\n",   // unknown key
+		"Stacks: [panther-core]\n",              // wrong shape
+		`{"Extras": {"Keep": ["glue"]}}`,        // unknown key in JSON
+	} {
+		_, err := parseTeardownConfig([]byte(body))
+		assert.Error(t, err, body)
+	}
+
+	for _, body := range []string{
+		"Stacks:\n  Delete: [panther-other]\n",
+		"Extras:\n  Delete: [lambda]\n",
+		"Stacks:\n  Delete: [panther-core]\n  Retain: [panther-core]\n",
+		"Buckets:\n  Delete: [panther-data]\n  Retain: [panther-data]\n",
+	} {
+		config, err := parseTeardownConfig([]byte(body))
+		require.NoError(t, err, body)
+		_, err = config.plan("")
+		assert.Error(t, err, body)
+	}
+}
+
+func TestDefaultTeardownPlan(t *testing.T) {
+	plan := defaultTeardownPlan("")
+	assert.Len(t, plan.stacks, cfnstacks.NumStacks)
+	assert.Nil(t, plan.buckets)
+	assert.Empty(t, plan.extras)
+	assert.True(t, plan.buckets.includes("panther-data", nil))
+
+	config, err := parseTeardownConfig([]byte(""))
+	require.NoError(t, err)
+	plan, err = config.plan("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultTeardownPlan(""), plan)
+}
+
+func TestTeardownPlanSelectStacks(t *testing.T) {
+	plan := defaultTeardownPlan("")
+	require.NoError(t, plan.selectStacks("", cfnstacks.Bootstrap+", "+cfnstacks.LogAnalysis+","+cfnstacks.Cloudsec))
+	// Bootstrap is still last
+	assert.Equal(t, []string{cfnstacks.Cloudsec, cfnstacks.LogAnalysis, cfnstacks.Bootstrap}, plan.stacks)
+	assert.False(t, plan.stacksOnly)
+
+	// Stacks retained by TEARDOWN_CONFIG stay retained
+	plan = &teardownPlan{stacks: []string{cfnstacks.Core, cfnstacks.LogAnalysis}}
+	require.NoError(t, plan.selectStacks("", cfnstacks.LogAnalysis+","+cfnstacks.Cloudsec))
+	assert.Equal(t, []string{cfnstacks.LogAnalysis}, plan.stacks)
+	// Without bootstrap, the buckets and extra resources are left alone
+	assert.True(t, plan.stacksOnly)
+}
+
+func TestTeardownPlanSelectStacksInvalid(t *testing.T) {
+	for _, value := range []string{
+		"panther-log-analysis,panther-typo",
+		" , ",
+	} {
+		plan := defaultTeardownPlan("")
+		assert.Error(t, plan.selectStacks("", value), value)
+		// Nothing is deselected
+		assert.Len(t, plan.stacks, cfnstacks.NumStacks, value)
+	}
+
+	plan := defaultTeardownPlan("panther")
+	assert.Error(t, plan.selectStacks("panther", cfnstacks.LogAnalysis))
+	assert.Equal(t, []string{"panther"}, plan.stacks)
+}
@@ -0,0 +1,354 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+
+	"github.com/panther-labs/panther/internal/log_analysis/awsglue"
+	"github.com/panther-labs/panther/pkg/awscfn"
+)
+
+// ECR repos created by the bootstrap stack
+var pantherRepoNames = []string{"panther-web"}
+
+// IAM roles created by the Panther stacks with an explicit name, suffixed with "-<region>".
+//
+// All other roles are named by CloudFormation and start with the name of the stack which created them.
+var pantherRoleNames = []string{"panther-data-replication-role", "PantherInputDataLogProcessingRole"}
+
+// Clients used to discover Panther resources
+type inventoryClients struct {
+	cfn  cloudformationiface.CloudFormationAPI
+	s3   s3iface.S3API
+	glue glueiface.GlueAPI
+	ecr  ecriface.ECRAPI
+	iam  iamiface.IAMAPI
+}
+
+func newInventoryClients() *inventoryClients {
+	return &inventoryClients{
+		cfn:  cloudformation.New(awsSession),
+		s3:   s3.New(awsSession),
+		glue: glue.New(awsSession),
+		ecr:  ecr.New(awsSession),
+		iam:  iam.New(awsSession),
+	}
+}
+
+// Panther resources found in the account
+type teardownInventory struct {
+	stacks    []string
+	buckets   []string
+	databases []string
+	repos     []string
+	roles     []string
+}
+
+func (inv *teardownInventory) isEmpty() bool {
+	return len(inv.stacks)+len(inv.buckets)+len(inv.databases)+len(inv.repos)+len(inv.roles) == 0
+}
+
+// Inventory List leftover Panther resources without deleting anything
+func Inventory() {
+	getSession()
+	clients := newInventoryClients()
+	inventory, err := listPantherResources(clients, os.Getenv("STACK"), *awsSession.Config.Region)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if inventory.isEmpty() {
+		logger.Infof("no Panther resources found in %s", *awsSession.Config.Region)
+		return
+	}
+	logResources("CloudFormation stacks", inventory.stacks)
+	logBuckets(clients.s3, inventory.buckets)
+	logResources("Glue databases", inventory.databases)
+	logResources("ECR repositories", inventory.repos)
+	logResources("IAM roles", inventory.roles)
+}
+
+func logResources(kind string, names []string) {
+	logger.Infof("%d %s", len(names), kind)
+	for _, name := range names {
+		logger.Infof("    - %s", name)
+	}
+}
+
+// How teardown would handle a bucket, estimated from a bounded listing of its object versions.
+type bucketEstimate struct {
+	name string
+	// The number of object versions, capped at about s3MaxDeletes
+	objectVersions int
+	// True if teardown would set an expiration policy instead of deleting the objects directly
+	expirationPolicy bool
+}
+
+func (e *bucketEstimate) String() string {
+	if e.expirationPolicy {
+		return fmt.Sprintf("%s (%d+ object versions, expiration policy)", e.name, s3MaxDeletes)
+	}
+	return fmt.Sprintf("%s (%d object versions, direct delete)", e.name, e.objectVersions)
+}
+
+// Log the buckets along with how teardown would delete them, so operators can anticipate long teardowns.
+func logBuckets(client s3iface.S3API, buckets []string) {
+	logger.Infof("%d S3 buckets", len(buckets))
+	for _, name := range buckets {
+		estimate, err := estimateBucketDeletion(client, name)
+		if err != nil {
+			logger.Warnf("    - %s (%v)", name, err)
+			continue
+		}
+		logger.Infof("    - %s", estimate)
+	}
+}
+
+// Predict whether removeBucket would delete the objects directly or set an expiration policy.
+//
+// This only lists the object versions, nothing is modified.
+func estimateBucketDeletion(client s3iface.S3API, bucketName string) (*bucketEstimate, error) {
+	objectVersions, err := listObjectVersions(client, aws.String(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &bucketEstimate{
+		name:             bucketName,
+		objectVersions:   len(objectVersions),
+		expirationPolicy: useExpirationPolicy(len(objectVersions)),
+	}, nil
+}
+
+// Find all Panther resources using the same discovery logic as teardown.
+func listPantherResources(clients *inventoryClients, masterStack, region string) (*teardownInventory, error) {
+	var (
+		inventory teardownInventory
+		err       error
+	)
+	if inventory.stacks, err = listPantherStacks(clients.cfn, masterStack); err != nil {
+		return nil, err
+	}
+
+	buckets, err := listPantherBuckets(clients.s3, masterStack, nil)
+	if err != nil {
+		return nil, err
+	}
+	inventory.buckets = aws.StringValueSlice(buckets)
+
+	err = clients.glue.GetDatabasesPages(&glue.GetDatabasesInput{},
+		func(page *glue.GetDatabasesOutput, lastPage bool) bool {
+			for _, db := range page.DatabaseList {
+				if _, ok := awsglue.PantherDatabases[aws.StringValue(db.Name)]; ok {
+					inventory.databases = append(inventory.databases, aws.StringValue(db.Name))
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list glue databases: %v", err)
+	}
+
+	err = clients.ecr.DescribeRepositoriesPages(&ecr.DescribeRepositoriesInput{},
+		func(page *ecr.DescribeRepositoriesOutput, lastPage bool) bool {
+			for _, repo := range page.Repositories {
+				if containsString(pantherRepoNames, aws.StringValue(repo.RepositoryName)) {
+					inventory.repos = append(inventory.repos, aws.StringValue(repo.RepositoryName))
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ECR repositories: %v", err)
+	}
+
+	err = clients.iam.ListRolesPages(&iam.ListRolesInput{}, func(page *iam.ListRolesOutput, lastPage bool) bool {
+		for _, role := range page.Roles {
+			if isPantherRoleName(aws.StringValue(role.RoleName), masterStack, region) {
+				inventory.roles = append(inventory.roles, aws.StringValue(role.RoleName))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IAM roles: %v", err)
+	}
+
+	sort.Strings(inventory.buckets)
+	sort.Strings(inventory.databases)
+	sort.Strings(inventory.repos)
+	sort.Strings(inventory.roles)
+	return &inventory, nil
+}
+
+// Returns the Panther stacks which still exist along with their status.
+func listPantherStacks(client cloudformationiface.CloudFormationAPI, masterStack string) ([]string, error) {
+	var stacks []string
+	for _, name := range pantherStackNames(masterStack) {
+		response, err := client.DescribeStacks(&cloudformation.DescribeStacksInput{StackName: aws.String(name)})
+		if err != nil {
+			if awscfn.ErrStackDoesNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to describe stack %s: %v", name, err)
+		}
+		for _, stack := range response.Stacks {
+			stacks = append(stacks, fmt.Sprintf("%s (%s)", name, aws.StringValue(stack.StackStatus)))
+		}
+	}
+	return stacks, nil
+}
+
+// Returns true if the IAM role was created by this Panther deployment.
+//
+// Roles are matched exactly, other deployments and roles which merely look like Panther's are never selected.
+func isPantherRoleName(name, masterStack, region string) bool {
+	for _, roleName := range pantherRoleNames {
+		if name == roleName+"-"+region {
+			return true
+		}
+	}
+	for _, stack := range pantherStackNames(masterStack) {
+		if strings.HasPrefix(name, stack+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete the leftover Panther resources in the given extra categories (glue, ecr, iam).
+func destroyExtras(clients *inventoryClients, masterStack string, extras []string, summary *teardownResult) error {
+	inventory, err := listPantherResources(clients, masterStack, summary.Region)
+	if err != nil {
+		return err
+	}
+
+	var errCount int
+	deleteExtra := func(kind, name string, err error) {
+		summary.addExtra(kind, name, err)
+		if err != nil {
+			logger.Errorf("    - %s %s failed to delete: %v", kind, name, err)
+			errCount++
+			return
+		}
+		logger.Infof("    √ %s %s deleted", kind, name)
+	}
+
+	if containsString(extras, extraGlue) {
+		for _, name := range inventory.databases {
+			_, err := clients.glue.DeleteDatabase(&glue.DeleteDatabaseInput{Name: aws.String(name)})
+			deleteExtra(extraGlue, name, err)
+		}
+	}
+	if containsString(extras, extraECR) {
+		for _, name := range inventory.repos {
+			_, err := clients.ecr.DeleteRepository(&ecr.DeleteRepositoryInput{
+				Force:          aws.Bool(true), // delete the images as well
+				RepositoryName: aws.String(name),
+			})
+			deleteExtra(extraECR, name, err)
+		}
+	}
+	if containsString(extras, extraIAM) {
+		for _, name := range inventory.roles {
+			deleteExtra(extraIAM, name, deleteRole(clients.iam, name))
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("%d extra resource(s) failed to delete", errCount)
+	}
+	return nil
+}
+
+// Delete an IAM role, which first requires removing its instance profiles and policies.
+func deleteRole(client iamiface.IAMAPI, roleName string) error {
+	var profiles []*string
+	err := client.ListInstanceProfilesForRolePages(&iam.ListInstanceProfilesForRoleInput{RoleName: aws.String(roleName)},
+		func(page *iam.ListInstanceProfilesForRoleOutput, lastPage bool) bool {
+			for _, profile := range page.InstanceProfiles {
+				profiles = append(profiles, profile.InstanceProfileName)
+			}
+			return true
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list instance profiles: %v", err)
+	}
+	for _, profileName := range profiles {
+		_, err := client.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{
+			InstanceProfileName: profileName,
+			RoleName:            aws.String(roleName),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove role from instance profile %s: %v", aws.StringValue(profileName), err)
+		}
+	}
+
+	var attached []*string
+	err = client.ListAttachedRolePoliciesPages(&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)},
+		func(page *iam.ListAttachedRolePoliciesOutput, lastPage bool) bool {
+			for _, policy := range page.AttachedPolicies {
+				attached = append(attached, policy.PolicyArn)
+			}
+			return true
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list attached policies: %v", err)
+	}
+	for _, policyArn := range attached {
+		_, err := client.DetachRolePolicy(&iam.DetachRolePolicyInput{PolicyArn: policyArn, RoleName: aws.String(roleName)})
+		if err != nil {
+			return fmt.Errorf("failed to detach policy %s: %v", aws.StringValue(policyArn), err)
+		}
+	}
+
+	var inline []*string
+	err = client.ListRolePoliciesPages(&iam.ListRolePoliciesInput{RoleName: aws.String(roleName)},
+		func(page *iam.ListRolePoliciesOutput, lastPage bool) bool {
+			inline = append(inline, page.PolicyNames...)
+			return true
+		})
+	if err != nil {
+		return fmt.Errorf("failed to list inline policies: %v", err)
+	}
+	for _, policyName := range inline {
+		_, err := client.DeleteRolePolicy(&iam.DeleteRolePolicyInput{PolicyName: policyName, RoleName: aws.String(roleName)})
+		if err != nil {
+			return fmt.Errorf("failed to delete inline policy %s: %v", aws.StringValue(policyName), err)
+		}
+	}
+
+	_, err = client.DeleteRole(&iam.DeleteRoleInput{RoleName: aws.String(roleName)})
+	return err
+}
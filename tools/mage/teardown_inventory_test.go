@@ -0,0 +1,279 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/pkg/testutils"
+	"github.com/panther-labs/panther/tools/cfnstacks"
+)
+
+func TestListPantherResources(t *testing.T) {
+	cfnClient := &testutils.CloudFormationMock{}
+	for _, stack := range pantherStackNames("") {
+		switch stack {
+		case cfnstacks.Bootstrap, cfnstacks.LogAnalysis:
+			cfnClient.On("DescribeStacks", &cloudformation.DescribeStacksInput{StackName: aws.String(stack)}).Return(
+				&cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{
+					{StackName: aws.String(stack), StackStatus: aws.String("DELETE_FAILED")},
+				}}, nil).Once()
+		default:
+			cfnClient.On("DescribeStacks", &cloudformation.DescribeStacksInput{StackName: aws.String(stack)}).Return(
+				(*cloudformation.DescribeStacksOutput)(nil),
+				awserr.New("ValidationError", "Stack with id "+stack+" does not exist", nil)).Once()
+		}
+	}
+
+	s3Client := &testutils.S3Mock{}
+	s3Client.On("ListBuckets", mock.Anything).Return(&s3.ListBucketsOutput{
+		Buckets: []*s3.Bucket{{Name: aws.String("panther-bootstrap-auditlogs")}, {Name: aws.String("other-data")}},
+	}, nil).Once()
+	s3Client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("panther-bootstrap-auditlogs")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Panther", "Stack", "panther-bootstrap")}, nil).Once()
+	s3Client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("other-data")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Other")}, nil).Once()
+
+	glueClient := &testutils.GlueMock{}
+	glueClient.On("GetDatabasesPages", mock.Anything, mock.Anything).Return(&glue.GetDatabasesOutput{
+		DatabaseList: []*glue.Database{{Name: aws.String("panther_logs")}, {Name: aws.String("default")}},
+	}, nil).Once()
+
+	ecrClient := &testutils.EcrMock{}
+	ecrClient.On("DescribeRepositoriesPages", mock.Anything, mock.Anything).Return(&ecr.DescribeRepositoriesOutput{
+		Repositories: []*ecr.Repository{
+			{RepositoryName: aws.String("panther-web")},
+			{RepositoryName: aws.String("panther-web-dev")},
+			{RepositoryName: aws.String("app")},
+		},
+	}, nil).Once()
+
+	iamClient := &testutils.IamMock{}
+	iamClient.On("ListRolesPages", mock.Anything, mock.Anything).Return(&iam.ListRolesOutput{
+		Roles: []*iam.Role{
+			{RoleName: aws.String("PantherInputDataLogProcessingRole-us-east-1")},
+			{RoleName: aws.String("panther-log-analysis-FunctionRole-ABC123")},
+			{RoleName: aws.String("PantherInputDataLogProcessingRole-us-west-2")},
+			{RoleName: aws.String("PantherAuditRole-us-east-1")},
+			{RoleName: aws.String("OrganizationAccountAccessRole")},
+		},
+	}, nil).Once()
+
+	inventory, err := listPantherResources(&inventoryClients{
+		cfn:  cfnClient,
+		s3:   s3Client,
+		glue: glueClient,
+		ecr:  ecrClient,
+		iam:  iamClient,
+	}, "", "us-east-1")
+	require.NoError(t, err)
+	assert.Equal(t, &teardownInventory{
+		stacks:    []string{cfnstacks.LogAnalysis + " (DELETE_FAILED)", cfnstacks.Bootstrap + " (DELETE_FAILED)"},
+		buckets:   []string{"panther-bootstrap-auditlogs"},
+		databases: []string{"panther_logs"},
+		repos:     []string{"panther-web"},
+		roles:     []string{"PantherInputDataLogProcessingRole-us-east-1", "panther-log-analysis-FunctionRole-ABC123"},
+	}, inventory)
+	assert.False(t, inventory.isEmpty())
+
+	cfnClient.AssertExpectations(t)
+	s3Client.AssertExpectations(t)
+	glueClient.AssertExpectations(t)
+	ecrClient.AssertExpectations(t)
+	iamClient.AssertExpectations(t)
+}
+
+func TestIsPantherRoleName(t *testing.T) {
+	assert.True(t, isPantherRoleName("panther-data-replication-role-us-west-2", "", "us-west-2"))
+	assert.True(t, isPantherRoleName("panther-bootstrap-FunctionRole-1ABC", "", "us-west-2"))
+	assert.True(t, isPantherRoleName("acme-siem-Bootstrap-1ABC-FunctionRole", "acme-siem", "us-west-2"))
+	assert.True(t, isPantherRoleName("PantherInputDataLogProcessingRole-us-west-2", "acme-siem", "us-west-2"))
+	// same role deployed in another region
+	assert.False(t, isPantherRoleName("panther-data-replication-role-us-east-1", "", "us-west-2"))
+	// roles which only look like Panther's
+	assert.False(t, isPantherRoleName("PantherAuditRole-us-west-2", "", "us-west-2"))
+	assert.False(t, isPantherRoleName("panther-admin", "", "us-west-2"))
+	assert.False(t, isPantherRoleName("acme-siem-Bootstrap-1ABC-FunctionRole", "", "us-west-2"))
+	assert.False(t, isPantherRoleName("panther-bootstrap-FunctionRole-1ABC", "acme-siem", "us-west-2"))
+	// another deployment whose stack name starts with the master stack name
+	assert.False(t, isPantherRoleName("acme-siem2-Bootstrap-1ABC-FunctionRole", "acme-siem", "us-west-2"))
+	assert.False(t, isPantherRoleName("acme-siemFunctionRole", "acme-siem", "us-west-2"))
+}
+
+// Mock the calls deleteRole makes for a role with an instance profile and one policy of each kind
+func mockDeleteRole(client *testutils.IamMock, roleName string) {
+	client.On("ListInstanceProfilesForRolePages",
+		&iam.ListInstanceProfilesForRoleInput{RoleName: aws.String(roleName)}, mock.Anything).Return(
+		&iam.ListInstanceProfilesForRoleOutput{InstanceProfiles: []*iam.InstanceProfile{
+			{InstanceProfileName: aws.String(roleName + "-profile")},
+		}}, nil).Once()
+	client.On("RemoveRoleFromInstanceProfile", &iam.RemoveRoleFromInstanceProfileInput{
+		InstanceProfileName: aws.String(roleName + "-profile"),
+		RoleName:            aws.String(roleName),
+	}).Return(&iam.RemoveRoleFromInstanceProfileOutput{}, nil).Once()
+	client.On("ListAttachedRolePoliciesPages",
+		&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)}, mock.Anything).Return(
+		&iam.ListAttachedRolePoliciesOutput{AttachedPolicies: []*iam.AttachedPolicy{
+			{PolicyArn: aws.String("arn:aws:iam::aws:policy/ReadOnlyAccess")},
+		}}, nil).Once()
+	client.On("DetachRolePolicy", &iam.DetachRolePolicyInput{
+		PolicyArn: aws.String("arn:aws:iam::aws:policy/ReadOnlyAccess"),
+		RoleName:  aws.String(roleName),
+	}).Return(&iam.DetachRolePolicyOutput{}, nil).Once()
+	client.On("ListRolePoliciesPages", &iam.ListRolePoliciesInput{RoleName: aws.String(roleName)}, mock.Anything).Return(
+		&iam.ListRolePoliciesOutput{PolicyNames: aws.StringSlice([]string{"inline"})}, nil).Once()
+	client.On("DeleteRolePolicy", &iam.DeleteRolePolicyInput{
+		PolicyName: aws.String("inline"),
+		RoleName:   aws.String(roleName),
+	}).Return(&iam.DeleteRolePolicyOutput{}, nil).Once()
+	client.On("DeleteRole", &iam.DeleteRoleInput{RoleName: aws.String(roleName)}).
+		Return(&iam.DeleteRoleOutput{}, nil).Once()
+}
+
+func TestDeleteRole(t *testing.T) {
+	client := &testutils.IamMock{}
+	mockDeleteRole(client, "acme-siem-FunctionRole")
+
+	require.NoError(t, deleteRole(client, "acme-siem-FunctionRole"))
+	client.AssertExpectations(t)
+}
+
+func TestDeleteRoleInstanceProfileError(t *testing.T) {
+	client := &testutils.IamMock{}
+	client.On("ListInstanceProfilesForRolePages", mock.Anything, mock.Anything).Return(
+		&iam.ListInstanceProfilesForRoleOutput{InstanceProfiles: []*iam.InstanceProfile{
+			{InstanceProfileName: aws.String("ec2-profile")},
+		}}, nil).Once()
+	client.On("RemoveRoleFromInstanceProfile", mock.Anything).
+		Return(&iam.RemoveRoleFromInstanceProfileOutput{}, errors.New("access denied")).Once()
+
+	err := deleteRole(client, "acme-siem-FunctionRole")
+	require.Error(t, err)
+	assert.Equal(t, "failed to remove role from instance profile ec2-profile: access denied", err.Error())
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DeleteRole", mock.Anything)
+}
+
+func TestDestroyExtras(t *testing.T) {
+	cfnClient := &testutils.CloudFormationMock{}
+	cfnClient.On("DescribeStacks", &cloudformation.DescribeStacksInput{StackName: aws.String("acme-siem")}).Return(
+		(*cloudformation.DescribeStacksOutput)(nil),
+		awserr.New("ValidationError", "Stack with id acme-siem does not exist", nil)).Once()
+
+	s3Client := &testutils.S3Mock{}
+	s3Client.On("ListBuckets", mock.Anything).Return(&s3.ListBucketsOutput{}, nil).Once()
+
+	glueClient := &testutils.GlueMock{}
+	glueClient.On("GetDatabasesPages", mock.Anything, mock.Anything).Return(&glue.GetDatabasesOutput{
+		DatabaseList: []*glue.Database{{Name: aws.String("panther_logs")}},
+	}, nil).Once()
+
+	ecrClient := &testutils.EcrMock{}
+	ecrClient.On("DescribeRepositoriesPages", mock.Anything, mock.Anything).Return(&ecr.DescribeRepositoriesOutput{
+		Repositories: []*ecr.Repository{{RepositoryName: aws.String("panther-web")}},
+	}, nil).Once()
+	ecrClient.On("DeleteRepository", &ecr.DeleteRepositoryInput{
+		Force:          aws.Bool(true),
+		RepositoryName: aws.String("panther-web"),
+	}).Return(&ecr.DeleteRepositoryOutput{}, errors.New("access denied")).Once()
+
+	iamClient := &testutils.IamMock{}
+	iamClient.On("ListRolesPages", mock.Anything, mock.Anything).Return(&iam.ListRolesOutput{
+		Roles: []*iam.Role{
+			{RoleName: aws.String("acme-siem-Bootstrap-1ABC-FunctionRole")},
+			{RoleName: aws.String("PantherAuditRole-us-west-2")},
+		},
+	}, nil).Once()
+	mockDeleteRole(iamClient, "acme-siem-Bootstrap-1ABC-FunctionRole")
+
+	summary := teardownResult{Region: "us-west-2"}
+	err := destroyExtras(&inventoryClients{
+		cfn:  cfnClient,
+		s3:   s3Client,
+		glue: glueClient,
+		ecr:  ecrClient,
+		iam:  iamClient,
+	}, "acme-siem", []string{extraECR, extraIAM}, &summary)
+	require.Error(t, err)
+	assert.Equal(t, "1 extra resource(s) failed to delete", err.Error())
+	assert.Equal(t, []string{"iam/acme-siem-Bootstrap-1ABC-FunctionRole"}, summary.ExtrasDeleted)
+	assert.Equal(t, []string{"ecr/panther-web"}, summary.ExtrasFailed)
+
+	cfnClient.AssertExpectations(t)
+	s3Client.AssertExpectations(t)
+	glueClient.AssertExpectations(t)
+	ecrClient.AssertExpectations(t)
+	iamClient.AssertExpectations(t)
+	// glue was not selected
+	glueClient.AssertNotCalled(t, "DeleteDatabase", mock.Anything)
+}
+
+func mockObjectVersions(client *testutils.S3Mock, bucketName string, count int) {
+	versions := make([]*s3.ObjectVersion, count)
+	for i := range versions {
+		versions[i] = &s3.ObjectVersion{Key: aws.String(fmt.Sprintf("%d.json", i)), VersionId: aws.String("1")}
+	}
+	client.On("ListObjectVersionsPages", &s3.ListObjectVersionsInput{Bucket: aws.String(bucketName)}, mock.Anything).
+		Return(&s3.ListObjectVersionsOutput{Versions: versions}, nil).Once()
+}
+
+func TestEstimateBucketDeletion(t *testing.T) {
+	client := &testutils.S3Mock{}
+	mockObjectVersions(client, "panther-small", 2)
+	mockObjectVersions(client, "panther-large", s3MaxDeletes)
+
+	estimate, err := estimateBucketDeletion(client, "panther-small")
+	require.NoError(t, err)
+	assert.Equal(t, &bucketEstimate{name: "panther-small", objectVersions: 2}, estimate)
+	assert.Equal(t, "panther-small (2 object versions, direct delete)", estimate.String())
+
+	estimate, err = estimateBucketDeletion(client, "panther-large")
+	require.NoError(t, err)
+	assert.Equal(t, &bucketEstimate{name: "panther-large", objectVersions: s3MaxDeletes, expirationPolicy: true}, estimate)
+	assert.Equal(t, "panther-large (10000+ object versions, expiration policy)", estimate.String())
+
+	// Nothing is modified
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "PutBucketAcl", mock.Anything)
+	client.AssertNotCalled(t, "DeleteObjects", mock.Anything)
+	client.AssertNotCalled(t, "DeleteBucket", mock.Anything)
+}
+
+func TestEstimateBucketDeletionError(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("ListObjectVersionsPages", mock.Anything, mock.Anything).
+		Return(&s3.ListObjectVersionsOutput{}, errors.New("access denied")).Once()
+
+	_, err := estimateBucketDeletion(client, "panther-data")
+	require.Error(t, err)
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,687 @@
+package mage
+
+/**
+ * Panther is a Cloud-Native SIEM for the Modern Security Team.
+ * Copyright (C) 2020 Panther Labs Inc
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/panther-labs/panther/pkg/awscfn"
+	"github.com/panther-labs/panther/pkg/testutils"
+	"github.com/panther-labs/panther/tools/cfnstacks"
+)
+
+const (
+	testAccountID = "111122223333"
+	testRoleARN   = "arn:aws:iam::111122223333:role/PantherTeardown"
+)
+
+func testSession(t *testing.T) *session.Session {
+	sess, err := session.NewSession(aws.NewConfig().
+		WithRegion("us-west-2").
+		WithCredentials(credentials.NewStaticCredentials("base-key", "base-secret", "")))
+	require.NoError(t, err)
+	return sess
+}
+
+func TestAssumeRoleSession(t *testing.T) {
+	client := &testutils.StsMock{}
+	expiration := time.Now().Add(time.Hour)
+	client.On("AssumeRoleWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&sts.AssumeRoleOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("assumed-key"),
+			SecretAccessKey: aws.String("assumed-secret"),
+			SessionToken:    aws.String("assumed-token"),
+			Expiration:      &expiration,
+		},
+	}, nil).Once()
+
+	base := testSession(t)
+	sess := assumeRoleSession(base, client, testRoleARN)
+	assert.Equal(t, "us-west-2", aws.StringValue(sess.Config.Region))
+
+	creds, err := sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "assumed-key", creds.AccessKeyID)
+	assert.Equal(t, testRoleARN, aws.StringValue(client.Calls[0].Arguments[1].(*sts.AssumeRoleInput).RoleArn))
+	client.AssertExpectations(t)
+
+	// The base session is left untouched
+	creds, err = base.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "base-key", creds.AccessKeyID)
+}
+
+func TestGetCallerIdentity(t *testing.T) {
+	client := &testutils.StsMock{}
+	client.On("GetCallerIdentity", mock.Anything).Return(&sts.GetCallerIdentityOutput{
+		Account: aws.String(testAccountID),
+		Arn:     aws.String(testRoleARN),
+	}, nil).Once()
+
+	identity, err := getCallerIdentity(client)
+	require.NoError(t, err)
+	assert.Equal(t, testAccountID, aws.StringValue(identity.Account))
+	assert.Equal(t, testRoleARN, aws.StringValue(identity.Arn))
+	client.AssertExpectations(t)
+}
+
+func TestGetCallerIdentityError(t *testing.T) {
+	client := &testutils.StsMock{}
+	client.On("GetCallerIdentity", mock.Anything).Return(
+		(*sts.GetCallerIdentityOutput)(nil), errors.New("ExpiredToken: the security token included in the request is expired"))
+
+	identity, err := getCallerIdentity(client)
+	assert.Nil(t, identity)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid or expired AWS credentials")
+	client.AssertExpectations(t)
+}
+
+func TestVerifyAccount(t *testing.T) {
+	identity := &sts.GetCallerIdentityOutput{
+		Account: aws.String(testAccountID),
+		Arn:     aws.String(testRoleARN),
+	}
+	assert.NoError(t, verifyAccount(identity, ""))
+	assert.NoError(t, verifyAccount(identity, testAccountID))
+	assert.Error(t, verifyAccount(identity, "999988887777"))
+}
+
+func testTags(keyValues ...string) []*s3.Tag {
+	var tags []*s3.Tag
+	for i := 0; i < len(keyValues); i += 2 {
+		tags = append(tags, &s3.Tag{Key: aws.String(keyValues[i]), Value: aws.String(keyValues[i+1])})
+	}
+	return tags
+}
+
+func TestIsPantherBucket(t *testing.T) {
+	// deployed from source
+	assert.True(t, isPantherBucket(testTags("Application", "Panther", "Stack", "panther-bootstrap"), ""))
+	assert.True(t, isPantherBucket(testTags("Application", "Panther", "Stack", "panther-bootstrap"), "panther"))
+
+	// deployed from the master template
+	tags := testTags("Stack", "panther-Bootstrap-1A2B3C4D5E6F", "Application", "Panther")
+	assert.True(t, isPantherBucket(tags, "panther"))
+	assert.False(t, isPantherBucket(tags, ""))
+	assert.False(t, isPantherBucket(tags, "other-stack"))
+
+	// a sibling deployment whose stack name merely starts with the master stack name
+	tags = testTags("Application", "Panther", "Stack", "panther-dev-Bootstrap-1A2B3C4D5E6F")
+	assert.True(t, isPantherBucket(tags, "panther-dev"))
+	assert.False(t, isPantherBucket(tags, "panther"))
+	assert.False(t, isPantherBucket(testTags("Application", "Panther", "Stack", "pantherdev"), "panther"))
+
+	// Application tag is always required
+	assert.False(t, isPantherBucket(testTags("Stack", "panther-bootstrap"), ""))
+	assert.False(t, isPantherBucket(testTags("Application", "Other", "Stack", "panther-Bootstrap-1A2B"), "panther"))
+	assert.False(t, isPantherBucket(nil, "panther"))
+}
+
+func mockBucketObjects(client *testutils.S3Mock, bucketName string) {
+	client.On("GetBucketRequestPayment", mock.Anything).Return(&s3.GetBucketRequestPaymentOutput{
+		Payer: aws.String(s3.PayerBucketOwner),
+	}, nil).Once()
+	client.On("PutBucketAcl", mock.Anything).Return(&s3.PutBucketAclOutput{}, nil).Once()
+	client.On("ListObjectVersionsPages", mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		DeleteMarkers: []*s3.DeleteMarkerEntry{{Key: aws.String("deleted.json"), VersionId: aws.String("2")}},
+		Versions:      []*s3.ObjectVersion{{Key: aws.String("data.json"), VersionId: aws.String("1")}},
+	}, nil).Once()
+	client.On("DeleteObjects", &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &s3.Delete{Objects: []*s3.ObjectIdentifier{
+			{Key: aws.String("deleted.json"), VersionId: aws.String("2")},
+			{Key: aws.String("data.json"), VersionId: aws.String("1")},
+		}},
+	}).Return(&s3.DeleteObjectsOutput{}, nil).Once()
+}
+
+func mockBucketEmpty(client *testutils.S3Mock, bucketName string, empty bool) {
+	response := &s3.ListObjectVersionsOutput{}
+	if !empty {
+		response.Versions = []*s3.ObjectVersion{{Key: aws.String("late.json"), VersionId: aws.String("3")}}
+	}
+	client.On("ListObjectVersions", &s3.ListObjectVersionsInput{Bucket: aws.String(bucketName), MaxKeys: aws.Int64(1)}).
+		Return(response, nil).Once()
+}
+
+func TestRemoveBucket(t *testing.T) {
+	client := &testutils.S3Mock{}
+	mockBucketObjects(client, "panther-data")
+	mockBucketEmpty(client, "panther-data", true)
+	client.On("DeleteBucket", &s3.DeleteBucketInput{Bucket: aws.String("panther-data")}).
+		Return(&s3.DeleteBucketOutput{}, nil).Once()
+
+	objectVersions, err := removeBucket(client, aws.String("panther-data"), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, objectVersions)
+	client.AssertExpectations(t)
+}
+
+func TestDeleteEmptyBucketRetry(t *testing.T) {
+	client := &testutils.S3Mock{}
+	mockBucketEmpty(client, "panther-data", true)
+	client.On("DeleteBucket", &s3.DeleteBucketInput{Bucket: aws.String("panther-data")}).
+		Return(&s3.DeleteBucketOutput{}, awserr.New("BucketNotEmpty", "The bucket you tried to delete is not empty", nil)).Once()
+	mockBucketEmpty(client, "panther-data", false)
+	mockBucketEmpty(client, "panther-data", true)
+	client.On("DeleteBucket", &s3.DeleteBucketInput{Bucket: aws.String("panther-data")}).
+		Return(&s3.DeleteBucketOutput{}, nil).Once()
+
+	require.NoError(t, deleteEmptyBucket(client, aws.String("panther-data"), time.Millisecond))
+	client.AssertExpectations(t)
+}
+
+func TestDeleteEmptyBucketGiveUp(t *testing.T) {
+	client := &testutils.S3Mock{}
+	for i := 0; i < deleteBucketAttempts; i++ {
+		mockBucketEmpty(client, "panther-data", false)
+	}
+
+	err := deleteEmptyBucket(client, aws.String("panther-data"), time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "still not empty")
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DeleteBucket", mock.Anything)
+}
+
+func TestRemoveBucketEmptyOnly(t *testing.T) {
+	client := &testutils.S3Mock{}
+	mockBucketObjects(client, "panther-data")
+
+	objectVersions, err := removeBucket(client, aws.String("panther-data"), true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, objectVersions)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DeleteBucket", mock.Anything)
+}
+
+func TestRemoveBucketRequesterPays(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("GetBucketRequestPayment", &s3.GetBucketRequestPaymentInput{Bucket: aws.String("panther-data")}).
+		Return(&s3.GetBucketRequestPaymentOutput{Payer: aws.String(s3.PayerRequester)}, nil).Once()
+
+	_, err := removeBucket(client, aws.String("panther-data"), false)
+	require.Error(t, err)
+	require.IsType(t, &manualBucketError{}, err)
+	assert.Contains(t, err.Error(), "requester pays")
+	client.AssertExpectations(t)
+	// The bucket is left untouched
+	client.AssertNotCalled(t, "PutBucketAcl", mock.Anything)
+	client.AssertNotCalled(t, "ListObjectVersionsPages", mock.Anything, mock.Anything)
+}
+
+func TestRemoveBucketAclAccessDenied(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("GetBucketRequestPayment", mock.Anything).Return(&s3.GetBucketRequestPaymentOutput{}, nil).Once()
+	client.On("PutBucketAcl", mock.Anything).Return(&s3.PutBucketAclOutput{},
+		awserr.New("AccessDenied", "Access Denied", nil)).Once()
+
+	_, err := removeBucket(client, aws.String("panther-data"), false)
+	require.Error(t, err)
+	require.IsType(t, &manualBucketError{}, err)
+	assert.Contains(t, err.Error(), "access denied updating the bucket ACL")
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "ListObjectVersionsPages", mock.Anything, mock.Anything)
+}
+
+func TestDestroyPantherBucketsAccessDenied(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("ListBuckets", mock.Anything).Return(&s3.ListBucketsOutput{
+		Buckets: []*s3.Bucket{{Name: aws.String("panther-data")}},
+	}, nil).Once()
+	client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("panther-data")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Panther", "Stack", "panther-bootstrap")}, nil).Once()
+	client.On("GetBucketRequestPayment", mock.Anything).Return(&s3.GetBucketRequestPaymentOutput{}, nil).Once()
+	client.On("PutBucketAcl", mock.Anything).Return(&s3.PutBucketAclOutput{}, nil).Once()
+	client.On("ListObjectVersionsPages", mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{},
+		awserr.New("AccessDenied", "Access Denied", nil)).Once()
+
+	var summary teardownResult
+	// Skipped buckets are not failures
+	require.NoError(t, destroyPantherBuckets(client, "", nil, false, &summary))
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DeleteBucket", mock.Anything)
+	assert.Equal(t, []string{"panther-data"}, summary.BucketsManual)
+	assert.Empty(t, summary.BucketsFailed)
+	assert.Empty(t, summary.BucketsHandled)
+	assert.Empty(t, summary.Audit)
+}
+
+func TestDestroyPantherBucketsFailure(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("ListBuckets", mock.Anything).Return(&s3.ListBucketsOutput{
+		Buckets: []*s3.Bucket{{Name: aws.String("panther-data")}, {Name: aws.String("other-data")}},
+	}, nil).Once()
+	client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("panther-data")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Panther", "Stack", "panther-bootstrap")}, nil).Once()
+	client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("other-data")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Other")}, nil).Once()
+	client.On("GetBucketRequestPayment", mock.Anything).Return(&s3.GetBucketRequestPaymentOutput{}, nil).Once()
+	client.On("PutBucketAcl", mock.Anything).Return(&s3.PutBucketAclOutput{},
+		awserr.New("ServiceUnavailable", "Please reduce your request rate", nil)).Once()
+
+	var summary teardownResult
+	err := destroyPantherBuckets(client, "", nil, false, &summary)
+	require.Error(t, err)
+	client.AssertExpectations(t)
+	assert.Equal(t, []string{"panther-data"}, summary.BucketsFailed)
+	assert.Empty(t, summary.BucketsHandled)
+	assert.Equal(t, 4, (&teardownResult{bucketsErr: err}).exitCode())
+}
+
+func TestRetainPantherBuckets(t *testing.T) {
+	client := &testutils.S3Mock{}
+	client.On("ListBuckets", mock.Anything).Return(&s3.ListBucketsOutput{
+		Buckets: []*s3.Bucket{{Name: aws.String("panther-data")}, {Name: aws.String("other-data")}},
+	}, nil).Once()
+	client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("panther-data")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Panther", "Stack", "panther-bootstrap")}, nil).Once()
+	client.On("GetBucketTagging", &s3.GetBucketTaggingInput{Bucket: aws.String("other-data")}).Return(
+		&s3.GetBucketTaggingOutput{TagSet: testTags("Application", "Other")}, nil).Once()
+
+	var summary teardownResult
+	retainPantherBuckets(client, "", nil, &summary)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "ListObjectVersionsPages", mock.Anything, mock.Anything)
+	client.AssertNotCalled(t, "DeleteBucket", mock.Anything)
+	assert.Equal(t, []string{"panther-data"}, summary.BucketsRetained)
+	assert.Empty(t, summary.BucketsHandled)
+	assert.Empty(t, summary.Audit)
+	assert.Equal(t, 0, summary.exitCode())
+}
+
+func TestDestroyLeftoversStacksOnly(t *testing.T) {
+	plan := defaultTeardownPlan("")
+	plan.extras = []string{extraIAM}
+	require.NoError(t, plan.selectStacks("", cfnstacks.LogAnalysis))
+
+	s3Client := &testutils.S3Mock{}
+	iamClient := &testutils.IamMock{}
+	var summary teardownResult
+	destroyLeftovers(&inventoryClients{s3: s3Client, iam: iamClient}, "", plan, &summary)
+
+	// Bootstrap was not deleted, nothing else is touched
+	assert.True(t, summary.BucketsSkipped)
+	assert.Equal(t, 0, summary.exitCode())
+	s3Client.AssertNotCalled(t, "ListBuckets", mock.Anything)
+	iamClient.AssertNotCalled(t, "ListRolesPages", mock.Anything, mock.Anything)
+}
+
+func TestDestroyLeftoversStacksFailed(t *testing.T) {
+	s3Client := &testutils.S3Mock{}
+	summary := teardownResult{stacksErr: errors.New("1 stack(s) failed to delete")}
+	destroyLeftovers(&inventoryClients{s3: s3Client}, "", defaultTeardownPlan(""), &summary)
+
+	assert.True(t, summary.BucketsSkipped)
+	s3Client.AssertNotCalled(t, "ListBuckets", mock.Anything)
+}
+
+func TestDestroyLeftoversBootstrap(t *testing.T) {
+	plan := defaultTeardownPlan("")
+	require.NoError(t, plan.selectStacks("", cfnstacks.LogAnalysis+","+cfnstacks.Bootstrap))
+
+	s3Client := &testutils.S3Mock{}
+	s3Client.On("ListBuckets", mock.Anything).Return(&s3.ListBucketsOutput{}, nil).Once()
+	var summary teardownResult
+	destroyLeftovers(&inventoryClients{s3: s3Client}, "", plan, &summary)
+
+	assert.False(t, summary.BucketsSkipped)
+	assert.NoError(t, summary.bucketsErr)
+	s3Client.AssertExpectations(t)
+}
+
+func TestTeardownExitCode(t *testing.T) {
+	stacksErr := errors.New("1 stack(s) failed to delete")
+	bucketsErr := errors.New("1 bucket(s) failed to delete")
+	assert.Equal(t, 0, (&teardownResult{}).exitCode())
+	assert.Equal(t, 2, (&teardownResult{stacksErr: stacksErr}).exitCode())
+	assert.Equal(t, 4, (&teardownResult{bucketsErr: bucketsErr}).exitCode())
+	assert.Equal(t, 6, (&teardownResult{stacksErr: stacksErr, bucketsErr: bucketsErr}).exitCode())
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &payload))
+	}))
+	defer server.Close()
+
+	summary := &teardownResult{
+		Account:  testAccountID,
+		Region:   "us-west-2",
+		Duration: "5m0s",
+	}
+	summary.addStack("panther-core", "", nil)
+	summary.addStack("panther-bootstrap", "", errors.New("DELETE_FAILED"))
+	summary.Audit[0].Timestamp = time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	summary.BucketsSkipped = true
+	summary.stacksErr = errors.New("1 stack(s) failed to delete")
+	summary.ExitCode = summary.exitCode()
+
+	require.NoError(t, notifyWebhook(server.Client(), server.URL, summary))
+	assert.Equal(t, map[string]interface{}{
+		"account":         testAccountID,
+		"region":          "us-west-2",
+		"stacksDeleted":   []interface{}{"panther-core"},
+		"stacksFailed":    []interface{}{"panther-bootstrap"},
+		"bucketsHandled":  nil,
+		"bucketsFailed":   nil,
+		"bucketsManual":   nil,
+		"bucketsRetained": nil,
+		"bucketsSkipped":  true,
+		"extrasDeleted":   nil,
+		"extrasFailed":    nil,
+		"duration":        "5m0s",
+		"exitCode":        float64(2),
+		"startedAt":       "0001-01-01T00:00:00Z",
+		"finishedAt":      "0001-01-01T00:00:00Z",
+		"audit": []interface{}{
+			map[string]interface{}{
+				"type":      "stack",
+				"id":        "panther-core",
+				"action":    "deleted",
+				"timestamp": "2020-01-02T15:04:05Z",
+			},
+		},
+	}, payload)
+}
+
+func TestNotifyWebhookFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	assert.Error(t, notifyWebhook(server.Client(), server.URL, &teardownResult{}))
+}
+
+func TestIsManagementAccount(t *testing.T) {
+	organization := &organizations.DescribeOrganizationOutput{
+		Organization: &organizations.Organization{MasterAccountId: aws.String(testAccountID)},
+	}
+
+	client := &testutils.OrganizationsMock{}
+	client.On("DescribeOrganization", mock.Anything).Return(organization, nil).Twice()
+	isManagement, err := isManagementAccount(client, testAccountID)
+	require.NoError(t, err)
+	assert.True(t, isManagement)
+	isManagement, err = isManagementAccount(client, "444455556666")
+	require.NoError(t, err)
+	assert.False(t, isManagement)
+	client.AssertExpectations(t)
+
+	client = &testutils.OrganizationsMock{}
+	client.On("DescribeOrganization", mock.Anything).Return((*organizations.DescribeOrganizationOutput)(nil),
+		awserr.New(organizations.ErrCodeAWSOrganizationsNotInUseException, "not in an organization", nil)).Once()
+	isManagement, err = isManagementAccount(client, testAccountID)
+	require.NoError(t, err)
+	assert.False(t, isManagement)
+	client.AssertExpectations(t)
+
+	client = &testutils.OrganizationsMock{}
+	client.On("DescribeOrganization", mock.Anything).Return((*organizations.DescribeOrganizationOutput)(nil),
+		awserr.New(organizations.ErrCodeAccessDeniedException, "access denied", nil)).Once()
+	_, err = isManagementAccount(client, testAccountID)
+	require.Error(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNeedsManagementConfirmation(t *testing.T) {
+	client := &testutils.OrganizationsMock{}
+	client.On("DescribeOrganization", mock.Anything).Return(&organizations.DescribeOrganizationOutput{
+		Organization: &organizations.Organization{MasterAccountId: aws.String(testAccountID)},
+	}, nil).Twice()
+	assert.True(t, needsManagementConfirmation(client, testAccountID))
+	assert.False(t, needsManagementConfirmation(client, "444455556666"))
+	client.AssertExpectations(t)
+
+	client = &testutils.OrganizationsMock{}
+	client.On("DescribeOrganization", mock.Anything).Return((*organizations.DescribeOrganizationOutput)(nil),
+		awserr.New(organizations.ErrCodeAWSOrganizationsNotInUseException, "not in an organization", nil)).Once()
+	assert.False(t, needsManagementConfirmation(client, testAccountID))
+	client.AssertExpectations(t)
+
+	// Fail closed if the organization cannot be described
+	client = &testutils.OrganizationsMock{}
+	client.On("DescribeOrganization", mock.Anything).Return((*organizations.DescribeOrganizationOutput)(nil),
+		awserr.New(organizations.ErrCodeAccessDeniedException, "access denied", nil)).Once()
+	assert.True(t, needsManagementConfirmation(client, testAccountID))
+	client.AssertExpectations(t)
+}
+
+func testStackID(name string) *string {
+	return aws.String("arn:aws:cloudformation:us-west-2:" + testAccountID + ":stack/" + name + "/1a2b3c4d")
+}
+
+func TestOrderStackDeletion(t *testing.T) {
+	client := &testutils.CloudFormationMock{}
+	client.On("ListExportsPages", mock.Anything, mock.Anything).Return(&cloudformation.ListExportsOutput{
+		Exports: []*cloudformation.Export{
+			{Name: aws.String("CoreApiUrl"), ExportingStackId: testStackID("panther-core")},
+			{Name: aws.String("Unused"), ExportingStackId: testStackID("panther-core")},
+			{Name: aws.String("OtherExport"), ExportingStackId: testStackID("other-stack")},
+		},
+	}, nil).Once()
+	client.On("ListImportsPages", &cloudformation.ListImportsInput{ExportName: aws.String("CoreApiUrl")}, mock.Anything).
+		Return(&cloudformation.ListImportsOutput{
+			Imports: []*string{aws.String("panther-cloud-security"), aws.String("unrelated-stack")},
+		}, nil).Once()
+	client.On("ListImportsPages", &cloudformation.ListImportsInput{ExportName: aws.String("Unused")}, mock.Anything).
+		Return((*cloudformation.ListImportsOutput)(nil),
+			awserr.New("ValidationError", "Export 'Unused' is not imported by any stack.", nil)).Once()
+
+	waves := orderStackDeletion(client, []string{"panther-core", "panther-cloud-security", "panther-web"})
+	assert.Equal(t, [][]string{{"panther-cloud-security", "panther-web"}, {"panther-core"}}, waves)
+	client.AssertExpectations(t)
+}
+
+func TestOrderStackDeletionFallback(t *testing.T) {
+	client := &testutils.CloudFormationMock{}
+	client.On("ListExportsPages", mock.Anything, mock.Anything).Return(
+		(*cloudformation.ListExportsOutput)(nil), errors.New("AccessDenied")).Once()
+
+	stacks := []string{"panther-core", "panther-cloud-security"}
+	assert.Equal(t, [][]string{stacks}, orderStackDeletion(client, stacks))
+	client.AssertExpectations(t)
+}
+
+func TestDeleteStackRetaining(t *testing.T) {
+	client := &testutils.CloudFormationMock{}
+	client.On("DeleteStack", &cloudformation.DeleteStackInput{StackName: aws.String("panther-core")}).
+		Return(&cloudformation.DeleteStackOutput{}, nil).Once()
+	now := time.Now()
+	client.On("DescribeStackEventsPages", mock.Anything, mock.Anything).Return(&cloudformation.DescribeStackEventsOutput{
+		StackEvents: []*cloudformation.StackEvent{
+			{
+				LogicalResourceId: aws.String("panther-core"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteFailed),
+				Timestamp:         aws.Time(now.Add(time.Minute)),
+			},
+			{
+				LogicalResourceId: aws.String("ApiFunction"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteFailed),
+				Timestamp:         aws.Time(now.Add(time.Minute)),
+			},
+			{
+				LogicalResourceId: aws.String("ApiFunction"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteInProgress),
+				Timestamp:         aws.Time(now.Add(time.Minute)),
+			},
+			{
+				// Failure from a previous teardown
+				LogicalResourceId: aws.String("OldFunction"),
+				ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteFailed),
+				Timestamp:         aws.Time(now.Add(-time.Hour)),
+			},
+		},
+	}, nil).Once()
+	client.On("DeleteStack", &cloudformation.DeleteStackInput{
+		StackName:       aws.String("panther-core"),
+		RetainResources: aws.StringSlice([]string{"ApiFunction"}),
+	}).Return(&cloudformation.DeleteStackOutput{}, nil).Once()
+
+	// The stack fails to delete until ApiFunction is retained
+	var waits int
+	wait := func() error {
+		waits++
+		if waits == 1 {
+			return &awscfn.StackStatusError{StackName: "panther-core", Status: cloudformation.StackStatusDeleteFailed}
+		}
+		return nil
+	}
+	require.NoError(t, deleteStackRetaining(client, "panther-core", forceDestroyRetries, wait))
+	assert.Equal(t, 2, waits)
+	client.AssertExpectations(t)
+}
+
+func TestDeleteStackRetainingDisabled(t *testing.T) {
+	client := &testutils.CloudFormationMock{}
+	client.On("DeleteStack", mock.Anything).Return(&cloudformation.DeleteStackOutput{}, nil).Once()
+
+	wait := func() error {
+		return &awscfn.StackStatusError{StackName: "panther-core", Status: cloudformation.StackStatusDeleteFailed}
+	}
+	err := deleteStackRetaining(client, "panther-core", 0, wait)
+	require.EqualError(t, err, cloudformation.StackStatusDeleteFailed)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DescribeStackEventsPages", mock.Anything, mock.Anything)
+}
+
+func TestDeleteStackRetainingOtherError(t *testing.T) {
+	client := &testutils.CloudFormationMock{}
+	client.On("DeleteStack", mock.Anything).Return(&cloudformation.DeleteStackOutput{}, nil).Twice()
+
+	// Only DELETE_FAILED stacks are retried, even if the error message looks the same
+	wait := func() error { return errors.New(cloudformation.StackStatusDeleteFailed) }
+	require.Error(t, deleteStackRetaining(client, "panther-core", forceDestroyRetries, wait))
+
+	wait = func() error {
+		return &awscfn.StackStatusError{StackName: "panther-core", Status: cloudformation.StackStatusRollbackComplete}
+	}
+	err := deleteStackRetaining(client, "panther-core", forceDestroyRetries, wait)
+	require.EqualError(t, err, cloudformation.StackStatusRollbackComplete)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DescribeStackEventsPages", mock.Anything, mock.Anything)
+}
+
+func TestDeleteStackRetainingGivesUp(t *testing.T) {
+	client := &testutils.CloudFormationMock{}
+	client.On("DeleteStack", mock.Anything).Return(&cloudformation.DeleteStackOutput{}, nil).Times(3)
+	client.On("DescribeStackEventsPages", mock.Anything, mock.Anything).Return(&cloudformation.DescribeStackEventsOutput{
+		StackEvents: []*cloudformation.StackEvent{{
+			LogicalResourceId: aws.String("ApiFunction"),
+			ResourceStatus:    aws.String(cloudformation.ResourceStatusDeleteFailed),
+			Timestamp:         aws.Time(time.Now().Add(time.Minute)),
+		}},
+	}, nil).Twice()
+
+	wait := func() error {
+		return &awscfn.StackStatusError{StackName: "panther-core", Status: cloudformation.StackStatusDeleteFailed}
+	}
+	err := deleteStackRetaining(client, "panther-core", 2, wait)
+	require.EqualError(t, err, cloudformation.StackStatusDeleteFailed)
+	client.AssertExpectations(t)
+}
+
+func TestCountdown(t *testing.T) {
+	start := time.Now()
+	require.NoError(t, countdown(context.Background(), 30*time.Millisecond, 10*time.Millisecond))
+	assert.True(t, time.Since(start) >= 30*time.Millisecond)
+}
+
+func TestCountdownCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	assert.Equal(t, context.Canceled, countdown(ctx, time.Minute, time.Second))
+	assert.True(t, time.Since(start) < time.Minute)
+}
+
+func TestDeleteStacksConcurrently(t *testing.T) {
+	stacks := []string{"a", "b", "c", "d", "e", "f", "g"}
+	var (
+		mu                  sync.Mutex
+		inFlight, maxFlight int
+	)
+	deleteFunc := func(stack string) (string, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxFlight {
+			maxFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if stack == "c" {
+			return "", errors.New("delete failed")
+		}
+		return "id-" + stack, nil
+	}
+
+	var deleted, failed []string
+	deleteStacksConcurrently(stacks, 2, deleteFunc, func(result deleteStackResult) {
+		if result.err != nil {
+			failed = append(failed, result.stackName)
+		} else {
+			assert.Equal(t, "id-"+result.stackName, result.stackID)
+			deleted = append(deleted, result.stackName)
+		}
+	})
+	assert.Equal(t, 2, maxFlight)
+	assert.ElementsMatch(t, []string{"a", "b", "d", "e", "f", "g"}, deleted)
+	assert.Equal(t, []string{"c"}, failed)
+
+	// No limit by default
+	maxFlight = 0
+	deleteStacksConcurrently(stacks, 0, deleteFunc, func(deleteStackResult) {})
+	assert.Equal(t, len(stacks), maxFlight)
+}